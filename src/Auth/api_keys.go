@@ -0,0 +1,515 @@
+package Auth
+
+import (
+	"GServer/Config"
+	"GServer/Defaults"
+	"GServer/Logger"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SCOPE_CATALOG_READ = "catalog:read"
+	SCOPE_WEBHOOKS     = "webhooks"
+	SCOPE_ADMIN        = "admin"
+
+	API_KEY_SOURCE_CONFIG = "config"
+	API_KEY_SOURCE_ISSUED = "issued"
+
+	// Keys look like "gsk_<id>_<secret>", the id lets operators tell keys apart in logs.
+	API_KEY_PREFIX = "gsk_"
+
+	// Quota value turning the quota off for a key.
+	UNLIMITED_QUOTA = -1
+)
+
+var Scopes []string = []string{
+	SCOPE_CATALOG_READ,
+	SCOPE_WEBHOOKS,
+	SCOPE_ADMIN,
+}
+
+type ApiKey struct {
+	Id   string
+	Name string
+
+	Hash string
+
+	Scopes []string
+
+	QuotaRequests int
+	QuotaPeriod   time.Duration
+
+	Source string
+
+	CreatedAt time.Time
+	RevokedAt time.Time
+
+	Revoked bool
+}
+
+type IssuedApiKey struct {
+	Key    string
+	ApiKey *ApiKey
+}
+
+var ErrInvalidApiKey error = errors.New("Invalid API key")
+var ErrApiKeyNotFound error = errors.New("API key not found")
+
+var Enabled bool = false
+
+var Settings Config.ConfigApiKeys = Config.ConfigApiKeys{}
+
+var keysFilePath string = ""
+
+// Set when the keys file couldn't be read, saving would overwrite the keys in it.
+var keysFileError error = nil
+
+var keys []*ApiKey = nil
+var keysByHash map[string]*ApiKey = nil
+
+var keysMutex sync.RWMutex
+
+func HashApiKey(key string) string {
+	var hash [32]byte = sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func newRandomHex(size int) (string, error) {
+	var data []byte = make([]byte, size)
+
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+func ParseScopes(value string) ([]string, error) {
+	var scopes []string = []string{}
+
+	for _, scope := range strings.Split(value, ",") {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+
+		if len(scope) < 1 {
+			continue
+		}
+
+		if !slices.Contains(Scopes, scope) {
+			return nil, errors.New("Unknown scope '" + scope + "'")
+		}
+
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes, nil
+}
+
+func (this *ApiKey) HasScope(scope string) bool {
+	return slices.Contains(this.Scopes, scope) || slices.Contains(this.Scopes, SCOPE_ADMIN)
+}
+
+func (this *ApiKey) GetQuota() (int, time.Duration) {
+	var requests int = this.QuotaRequests
+	var period time.Duration = this.QuotaPeriod
+
+	if requests == 0 {
+		requests = Settings.DefaultQuotaRequests
+	}
+
+	if period <= 0 {
+		period = Settings.DefaultQuotaPeriod
+	}
+
+	return requests, period
+}
+
+func copyApiKey(key *ApiKey) *ApiKey {
+	var copied *ApiKey = new(ApiKey)
+
+	*copied = *key
+
+	copied.Scopes = append([]string{}, key.Scopes...)
+
+	return copied
+}
+
+// Must be called with the mutex held.
+func addApiKey(key *ApiKey) {
+	keys = append(keys, key)
+
+	if !key.Revoked {
+		keysByHash[key.Hash] = key
+	}
+}
+
+// Must be called with the mutex held.
+func saveIssuedApiKeys() error {
+	if keysFileError != nil {
+		return errors.New("API keys file couldn't be read, refusing to overwrite it: " + keysFileError.Error())
+	}
+
+	var issued []*ApiKey = []*ApiKey{}
+
+	for _, key := range keys {
+		if key.Source == API_KEY_SOURCE_ISSUED {
+			issued = append(issued, key)
+		}
+	}
+
+	data, err := json.MarshalIndent(issued, "", "\t")
+
+	if err != nil {
+		return err
+	}
+
+	var temporaryPath string = keysFilePath + ".tmp"
+
+	if err := os.WriteFile(temporaryPath, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, keysFilePath)
+}
+
+func loadIssuedApiKeys() ([]*ApiKey, error) {
+	data, err := os.ReadFile(keysFilePath)
+
+	if os.IsNotExist(err) {
+		return []*ApiKey{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var issued []*ApiKey = []*ApiKey{}
+
+	if err := json.Unmarshal(data, &issued); err != nil {
+		return nil, err
+	}
+
+	return issued, nil
+}
+
+// Creates a key with `scopes` and returns it once, only its hash is kept.
+func IssueApiKey(name string, scopes []string, quotaRequests int, quotaPeriod time.Duration) (*IssuedApiKey, error) {
+	if len(scopes) < 1 {
+		return nil, errors.New("A key needs at least one scope")
+	}
+
+	id, err := newRandomHex(4)
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newRandomHex(24)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var value string = API_KEY_PREFIX + id + "_" + secret
+
+	var key *ApiKey = new(ApiKey)
+
+	key.Id = id
+	key.Name = name
+
+	key.Hash = HashApiKey(value)
+
+	key.Scopes = scopes
+
+	key.QuotaRequests = quotaRequests
+	key.QuotaPeriod = quotaPeriod
+
+	key.Source = API_KEY_SOURCE_ISSUED
+
+	key.CreatedAt = time.Now()
+	key.RevokedAt = time.Time{}
+
+	key.Revoked = false
+
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+
+	addApiKey(key)
+
+	if err := saveIssuedApiKeys(); err != nil {
+		keys = keys[:len(keys)-1]
+
+		delete(keysByHash, key.Hash)
+
+		return nil, err
+	}
+
+	Logger.INFO("API key issued.", "id", key.Id, "name", key.Name, "scopes", key.Scopes)
+
+	var issued *IssuedApiKey = new(IssuedApiKey)
+
+	issued.Key = value
+	issued.ApiKey = copyApiKey(key)
+
+	return issued, nil
+}
+
+func RevokeApiKey(id string) (*ApiKey, error) {
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+
+	for _, key := range keys {
+		if key.Id != id {
+			continue
+		}
+
+		if key.Source == API_KEY_SOURCE_CONFIG {
+			return nil, errors.New("Key '" + id + "' is defined in the config file, remove it there")
+		}
+
+		if key.Revoked {
+			return copyApiKey(key), nil
+		}
+
+		key.Revoked = true
+		key.RevokedAt = time.Now()
+
+		delete(keysByHash, key.Hash)
+
+		if err := saveIssuedApiKeys(); err != nil {
+			key.Revoked = false
+			key.RevokedAt = time.Time{}
+
+			keysByHash[key.Hash] = key
+
+			return nil, err
+		}
+
+		resetQuota(key.Id)
+
+		Logger.INFO("API key revoked.", "id", key.Id, "name", key.Name)
+
+		return copyApiKey(key), nil
+	}
+
+	return nil, ErrApiKeyNotFound
+}
+
+// Returns the active key `value` belongs to.
+func Authenticate(value string) (*ApiKey, error) {
+	if !strings.HasPrefix(value, API_KEY_PREFIX) {
+		return nil, ErrInvalidApiKey
+	}
+
+	keysMutex.RLock()
+	defer keysMutex.RUnlock()
+
+	key, exists := keysByHash[HashApiKey(value)]
+
+	if !exists {
+		return nil, ErrInvalidApiKey
+	}
+
+	return copyApiKey(key), nil
+}
+
+// Returns every key, revoked ones included, sorted by creation time.
+func GetApiKeys() []*ApiKey {
+	keysMutex.RLock()
+	defer keysMutex.RUnlock()
+
+	var result []*ApiKey = []*ApiKey{}
+
+	for _, key := range keys {
+		result = append(result, copyApiKey(key))
+	}
+
+	sort.SliceStable(result, func(i int, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result
+}
+
+func loadSettings() {
+	Settings = Config.Get().ApiKeys
+
+	Enabled = Settings.Enabled
+
+	if len(Settings.KeysFile) < 1 {
+		Settings.KeysFile = Defaults.API_KEYS_FILE_NAME
+	}
+
+	if Settings.DefaultQuotaRequests == 0 {
+		Settings.DefaultQuotaRequests = Defaults.API_KEYS_DEFAULT_QUOTA_REQUESTS
+	}
+
+	if Settings.DefaultQuotaPeriod <= 0 {
+		Settings.DefaultQuotaPeriod = Defaults.API_KEYS_DEFAULT_QUOTA_PERIOD
+	}
+}
+
+// Writes the bootstrap key to a file only the server's user can read, keeping it
+// out of the logs.
+func writeBootstrapApiKey(key string) (string, error) {
+	path, err := Config.GetApplicationFilePath(Defaults.API_KEYS_BOOTSTRAP_FILE_NAME)
+
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	// A file left from an earlier start may have wider permissions.
+	if err := file.Chmod(0600); err != nil {
+		return "", err
+	}
+
+	if _, err := file.WriteString(key + "\n"); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Without any admin key nobody could issue keys, so one is created and written
+// to a file once.
+func issueBootstrapApiKey() {
+	keysMutex.RLock()
+
+	for _, key := range keys {
+		if !key.Revoked && slices.Contains(key.Scopes, SCOPE_ADMIN) {
+			keysMutex.RUnlock()
+			return
+		}
+	}
+
+	keysMutex.RUnlock()
+
+	issued, err := IssueApiKey("bootstrap admin", []string{SCOPE_ADMIN}, UNLIMITED_QUOTA, 0)
+
+	if err != nil {
+		Logger.ERROR("Couldn't issue bootstrap admin API key.", "error", err)
+		return
+	}
+
+	path, err := writeBootstrapApiKey(issued.Key)
+
+	if err != nil {
+		Logger.ERROR("Couldn't write bootstrap admin API key, revoking it.", "id", issued.ApiKey.Id, "error", err)
+
+		RevokeApiKey(issued.ApiKey.Id)
+		return
+	}
+
+	Logger.WARN("No admin API key exists, issued one. Store it and delete the file.", "id", issued.ApiKey.Id, "path", path)
+}
+
+func Initialize() {
+	Logger.INFO("Initializing API keys...")
+
+	loadSettings()
+
+	keysMutex.Lock()
+
+	keys = []*ApiKey{}
+	keysByHash = map[string]*ApiKey{}
+
+	for _, configKey := range Settings.Keys {
+		if configKey.Disabled {
+			continue
+		}
+
+		if len(configKey.Hash) != sha256.Size*2 {
+			Logger.WARN("Ignoring API key with invalid hash.", "id", configKey.Id)
+			continue
+		}
+
+		var key *ApiKey = new(ApiKey)
+
+		key.Id = configKey.Id
+		key.Name = configKey.Name
+
+		key.Hash = strings.ToLower(configKey.Hash)
+
+		key.Scopes = configKey.Scopes
+
+		key.QuotaRequests = configKey.QuotaRequests
+		key.QuotaPeriod = configKey.QuotaPeriod
+
+		key.Source = API_KEY_SOURCE_CONFIG
+
+		key.CreatedAt, _ = Config.GetLoadState()
+		key.RevokedAt = time.Time{}
+
+		key.Revoked = false
+
+		addApiKey(key)
+	}
+
+	path, err := Config.GetApplicationFilePath(Settings.KeysFile)
+
+	if err != nil {
+		Logger.ERROR("Couldn't resolve API keys file path.", "error", err)
+	}
+
+	keysFilePath = path
+
+	issued, err := loadIssuedApiKeys()
+
+	keysFileError = err
+
+	if err != nil {
+		Logger.ERROR("Couldn't read API keys file, issuing and revoking keys is disabled until it's fixed.", "path", keysFilePath, "error", err)
+	}
+
+	for _, key := range issued {
+		key.Source = API_KEY_SOURCE_ISSUED
+
+		addApiKey(key)
+	}
+
+	keysMutex.Unlock()
+
+	if Enabled && err == nil && len(keysFilePath) > 0 {
+		issueBootstrapApiKey()
+	}
+
+	quotaMutex.Lock()
+
+	quotas = map[string]*quotaWindow{}
+
+	quotaMutex.Unlock()
+
+	keysMutex.RLock()
+
+	var count int = len(keysByHash)
+
+	keysMutex.RUnlock()
+
+	Logger.INFO("API keys initialized.", "enabled", Enabled, "keys", count)
+}
+
+func Uninitialize() {
+	Logger.INFO("Uninitializing API keys...")
+
+	Logger.INFO("API keys uninitialized.")
+}
@@ -0,0 +1,78 @@
+package Auth
+
+import (
+	"sync"
+	"time"
+)
+
+type quotaWindow struct {
+	Start time.Time
+	Count int
+}
+
+type QuotaStatus struct {
+	Allowed bool
+
+	Limit     int
+	Remaining int
+
+	// Start of the next window, when the quota is restored.
+	Reset time.Time
+}
+
+var quotas map[string]*quotaWindow = map[string]*quotaWindow{}
+var quotaMutex sync.Mutex
+
+func resetQuota(id string) {
+	quotaMutex.Lock()
+	defer quotaMutex.Unlock()
+
+	delete(quotas, id)
+}
+
+// Counts a request against the fixed window quota of `key`. Rejected requests
+// aren't counted, so a client backing off regains access at the reset.
+func ConsumeQuota(key *ApiKey) *QuotaStatus {
+	var status *QuotaStatus = new(QuotaStatus)
+
+	limit, period := key.GetQuota()
+
+	status.Allowed = true
+
+	status.Limit = limit
+	status.Remaining = -1
+
+	status.Reset = time.Time{}
+
+	if limit < 0 {
+		return status
+	}
+
+	var now time.Time = time.Now()
+
+	quotaMutex.Lock()
+	defer quotaMutex.Unlock()
+
+	window, exists := quotas[key.Id]
+
+	if !exists || now.Sub(window.Start) >= period {
+		window = &quotaWindow{Start: now.Truncate(period), Count: 0}
+
+		quotas[key.Id] = window
+	}
+
+	status.Reset = window.Start.Add(period)
+
+	if window.Count >= limit {
+		status.Allowed = false
+		status.Remaining = 0
+
+		return status
+	}
+
+	window.Count++
+
+	status.Remaining = limit - window.Count
+
+	return status
+}
@@ -0,0 +1,390 @@
+package Catalog
+
+import (
+	"GServer/Logger"
+	"GServer/Movie"
+	"GServer/Tracing"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var Movies []*Movie.MovieDetails = nil
+
+var movieMatchKeys []*Movie.MovieMatchKey = nil
+var moviesByIMDBCode map[string]int = nil
+
+// Movie indexes by match key year and then title length, matching only compares
+// titles in the buckets a match can come from.
+var movieMatchBuckets map[float64]map[int][]int = nil
+
+var movieSlugs []string = nil
+var moviesBySlug map[string]int = nil
+
+var movieAddedTimes []time.Time = nil
+
+// Time of the last change to the catalog, feeds use it to answer conditional requests.
+var lastModified time.Time = time.Time{}
+
+var mutex sync.RWMutex
+
+// Replaces the match key of the movie at `index`, moving it to its new bucket.
+// Must be called with the catalog locked.
+func setMovieMatchKey(index int, key *Movie.MovieMatchKey) {
+	if previous := movieMatchKeys[index]; previous != nil {
+		var lengths map[int][]int = movieMatchBuckets[previous.Year]
+		var length int = len(previous.Title)
+
+		lengths[length] = slices.DeleteFunc(lengths[length], func(other int) bool {
+			return other == index
+		})
+
+		if len(lengths[length]) < 1 {
+			delete(lengths, length)
+		}
+
+		if len(lengths) < 1 {
+			delete(movieMatchBuckets, previous.Year)
+		}
+	}
+
+	movieMatchKeys[index] = key
+
+	var lengths map[int][]int = movieMatchBuckets[key.Year]
+
+	if lengths == nil {
+		lengths = map[int][]int{}
+
+		movieMatchBuckets[key.Year] = lengths
+	}
+
+	lengths[len(key.Title)] = append(lengths[len(key.Title)], index)
+
+	if len(key.IMDBCode) > 0 {
+		moviesByIMDBCode[key.IMDBCode] = index
+	}
+}
+
+// Returns the years whose movies can match a movie from `year`. Movies without a
+// year can match any year.
+func getMovieMatchYears(year float64) []float64 {
+	if year == 0 {
+		var years []float64 = make([]float64, 0, len(movieMatchBuckets))
+
+		for other := range movieMatchBuckets {
+			years = append(years, other)
+		}
+
+		return years
+	}
+
+	var years []float64 = []float64{0}
+
+	for distance := -Movie.MOVIE_MATCH_MAXIMUM_YEAR_DISTANCE; distance <= Movie.MOVIE_MATCH_MAXIMUM_YEAR_DISTANCE; distance++ {
+		years = append(years, year+float64(distance))
+	}
+
+	return years
+}
+
+func findMatchingMovieIndex(key *Movie.MovieMatchKey) int {
+	if len(key.IMDBCode) > 0 {
+		if index, exists := moviesByIMDBCode[key.IMDBCode]; exists {
+			return index
+		}
+	}
+
+	var bestIndex int = -1
+	var bestScore float64 = Movie.MOVIE_MATCH_SCORE_NONE
+
+	// Titles too different in length can't reach the minimum similarity, the range is
+	// widened by one for rounding and GetMovieMatchKeyScore checks the exact ratio.
+	var minimumLength int = int(math.Ceil(float64(len(key.Title))*Movie.MOVIE_MATCH_MINIMUM_TITLE_SIMILARITY)) - 1
+	var maximumLength int = int(math.Floor(float64(len(key.Title))/Movie.MOVIE_MATCH_MINIMUM_TITLE_SIMILARITY)) + 1
+
+	for _, year := range getMovieMatchYears(key.Year) {
+		var lengths map[int][]int = movieMatchBuckets[year]
+
+		for length := minimumLength; length <= maximumLength && lengths != nil; length++ {
+			for _, index := range lengths[length] {
+				var score float64 = Movie.GetMovieMatchKeyScore(movieMatchKeys[index], key)
+
+				// Lower indexes win ties, as they did when every movie was compared in order.
+				if score < Movie.MOVIE_MATCH_SCORE_THRESHOLD || score < bestScore || (score == bestScore && index > bestIndex) {
+					continue
+				}
+
+				bestIndex = index
+				bestScore = score
+			}
+		}
+	}
+
+	return bestIndex
+}
+
+func getMovieSlugBase(details *Movie.MovieDetails) string {
+	if len(details.Slug) > 0 {
+		return details.Slug
+	}
+
+	// Titles without any latin letters, fall back to the source identifiers.
+	var slug string = Movie.GetMovieSlug(details.SpecialIdentifier, 0)
+
+	if len(slug) > 0 {
+		return slug
+	}
+
+	return fmt.Sprintf("movie-%.0f", details.Id)
+}
+
+// Gives the movie stored at `index` a slug no other movie uses by suffixing its
+// base slug with "-2", "-3"... Must be called with the catalog locked.
+func assignUniqueMovieSlug(index int, details *Movie.MovieDetails) {
+	var previous string = movieSlugs[index]
+
+	if owner, exists := moviesBySlug[previous]; exists && owner == index {
+		delete(moviesBySlug, previous)
+	}
+
+	var base string = getMovieSlugBase(details)
+	var slug string = base
+
+	for n := 2; ; n++ {
+		owner, exists := moviesBySlug[slug]
+
+		if !exists || owner == index {
+			break
+		}
+
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	details.Slug = slug
+
+	movieSlugs[index] = slug
+	moviesBySlug[slug] = index
+}
+
+// Stores `details` in the catalog, merging it into the record of the same film
+// when one already exists. Returns the stored record and whether it is new.
+//
+// Stored records are never modified, merging replaces them with a merged copy so
+// records handed out by GetMovies stay consistent while the crawlers run.
+func StoreMovie(details *Movie.MovieDetails) (*Movie.MovieDetails, bool) {
+	return StoreMovieWithContext(context.Background(), details)
+}
+
+func StoreMovieWithContext(ctx context.Context, details *Movie.MovieDetails) (*Movie.MovieDetails, bool) {
+	ctx, span := Tracing.StartSpan(ctx, "catalog.store_movie",
+		attribute.String(Tracing.ATTRIBUTE_SOURCE, details.Source),
+		attribute.String(Tracing.ATTRIBUTE_MOVIE_TITLE, details.Title),
+	)
+
+	stored, isNew := storeMovie(ctx, details)
+
+	if stored != nil {
+		span.SetAttributes(
+			attribute.String(Tracing.ATTRIBUTE_MOVIE_SLUG, stored.Slug),
+			attribute.Bool("gserver.movie.new", isNew),
+		)
+	}
+
+	Tracing.EndSpan(span, nil)
+
+	return stored, isNew
+}
+
+func storeMovie(ctx context.Context, details *Movie.MovieDetails) (*Movie.MovieDetails, bool) {
+	if !Movie.IsMovieDetialsValid(details) {
+		return nil, false
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var index int = findMatchingMovieIndex(Movie.GetMovieMatchKey(details))
+
+	var stored *Movie.MovieDetails = nil
+	var isNew bool = index < 0
+
+	if isNew {
+		stored = Movie.CopyMovieDetails(details)
+
+		Movies = append(Movies, stored)
+		movieMatchKeys = append(movieMatchKeys, nil)
+		movieSlugs = append(movieSlugs, "")
+		movieAddedTimes = append(movieAddedTimes, time.Now())
+
+		index = len(Movies) - 1
+	} else {
+		stored = Movie.CopyMovieDetails(Movies[index])
+
+		Movie.MergeMovieDetails(stored, details)
+
+		Movies[index] = stored
+	}
+
+	Movie.NormalizeMovieDetailsTitle(stored)
+
+	assignUniqueMovieSlug(index, stored)
+
+	setMovieMatchKey(index, Movie.GetMovieMatchKey(stored))
+
+	registerMovieTorrents(ctx, index, stored)
+
+	indexMovie(index, stored)
+
+	lastModified = time.Now()
+
+	return stored, isNew
+}
+
+func StoreMovies(movies []*Movie.MovieDetails) (int, int) {
+	var added int = 0
+	var updated int = 0
+
+	for _, details := range movies {
+		stored, isNew := StoreMovie(details)
+
+		if stored == nil {
+			continue
+		}
+
+		if isNew {
+			added++
+		} else {
+			updated++
+		}
+	}
+
+	return added, updated
+}
+
+func GetMovies() []*Movie.MovieDetails {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return append([]*Movie.MovieDetails{}, Movies...)
+}
+
+func GetMovieBySlug(slug string) *Movie.MovieDetails {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	index, exists := moviesBySlug[strings.ToLower(slug)]
+
+	if !exists {
+		return nil
+	}
+
+	return Movies[index]
+}
+
+func GetMovieByIMDBCode(imdbCode string) *Movie.MovieDetails {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	index, exists := moviesByIMDBCode[strings.ToLower(imdbCode)]
+
+	if !exists {
+		return nil
+	}
+
+	return Movies[index]
+}
+
+type LatestMovie struct {
+	Movie *Movie.MovieDetails
+
+	AddedAt time.Time
+}
+
+// Returns up to `limit` movies matching `filter`, the most recently added first.
+func GetLatestMovies(filter *MovieFilter, limit int) []*LatestMovie {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var latest []*LatestMovie = []*LatestMovie{}
+
+	for i := len(Movies) - 1; i >= 0 && (limit < 1 || len(latest) < limit); i-- {
+		if !filter.Matches(Movies[i]) {
+			continue
+		}
+
+		var movie *LatestMovie = new(LatestMovie)
+
+		movie.Movie = Movies[i]
+		movie.AddedAt = movieAddedTimes[i]
+
+		latest = append(latest, movie)
+	}
+
+	return latest
+}
+
+func GetLastModified() time.Time {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return lastModified
+}
+
+func GetMovieCount() int {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return len(Movies)
+}
+
+// The catalog lives in memory, it's usable once Initialize allocated it.
+func IsInitialized() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return Movies != nil
+}
+
+func Initialize() {
+	Logger.INFO("Initializing catalog...")
+
+	mutex.Lock()
+
+	Movies = []*Movie.MovieDetails{}
+
+	movieMatchKeys = []*Movie.MovieMatchKey{}
+	moviesByIMDBCode = map[string]int{}
+	movieMatchBuckets = map[float64]map[int][]int{}
+
+	movieSlugs = []string{}
+	moviesBySlug = map[string]int{}
+
+	movieAddedTimes = []time.Time{}
+	lastModified = time.Now()
+
+	Torrents = map[string]*TorrentRecord{}
+
+	searchDocuments = []*searchDocument{}
+	searchPostings = map[string]map[int]float64{}
+	searchTotalLength = 0
+
+	searchSortedTerms = nil
+	searchTermsByLength = nil
+	searchSortedTermsDirty = false
+
+	mutex.Unlock()
+
+	Logger.INFO("Catalog initialized.")
+}
+
+func Uninitialize() {
+	Logger.INFO("Uninitializing catalog...")
+
+	Logger.INFO("Catalog uninitialized.")
+}
@@ -0,0 +1,181 @@
+package Catalog
+
+import (
+	"GServer/Movie"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type MovieFacetValue struct {
+	Value string
+	Count int
+}
+
+type MovieFacets struct {
+	Genres     []*MovieFacetValue
+	Decades    []*MovieFacetValue
+	Languages  []*MovieFacetValue
+	MPARatings []*MovieFacetValue
+	Qualities  []*MovieFacetValue
+	Sources    []*MovieFacetValue
+}
+
+type movieFacetCounter struct {
+	Values map[string]*MovieFacetValue
+}
+
+func newMovieFacetCounter() *movieFacetCounter {
+	var counter *movieFacetCounter = new(movieFacetCounter)
+
+	counter.Values = map[string]*MovieFacetValue{}
+
+	return counter
+}
+
+// Counts every distinct value once per movie, values differing only in case are
+// counted together under the first spelling seen.
+func (this *movieFacetCounter) Add(values []string) {
+	var seen map[string]bool = map[string]bool{}
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+
+		var key string = strings.ToLower(value)
+
+		if len(key) < 1 || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+
+		facetValue, exists := this.Values[key]
+
+		if !exists {
+			facetValue = new(MovieFacetValue)
+
+			facetValue.Value = value
+			facetValue.Count = 0
+
+			this.Values[key] = facetValue
+		}
+
+		facetValue.Count++
+	}
+}
+
+func (this *movieFacetCounter) Sorted() []*MovieFacetValue {
+	var values []*MovieFacetValue = []*MovieFacetValue{}
+
+	for _, value := range this.Values {
+		values = append(values, value)
+	}
+
+	sort.Slice(values, func(i int, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+
+		return values[i].Value < values[j].Value
+	})
+
+	return values
+}
+
+// Decades are sorted chronologically instead of by count, sidebars list them as a timeline.
+func (this *movieFacetCounter) SortedByValue() []*MovieFacetValue {
+	var values []*MovieFacetValue = []*MovieFacetValue{}
+
+	for _, value := range this.Values {
+		values = append(values, value)
+	}
+
+	sort.Slice(values, func(i int, j int) bool {
+		return values[i].Value < values[j].Value
+	})
+
+	return values
+}
+
+func getMovieQualities(details *Movie.MovieDetails) []string {
+	var qualities []string = []string{}
+
+	for _, torrent := range details.Torrents {
+		qualities = append(qualities, torrent.Quality)
+	}
+
+	return qualities
+}
+
+func getMovieSources(details *Movie.MovieDetails) []string {
+	return append([]string{details.Source}, details.Sources...)
+}
+
+// Returns the counts of every facet value among `movies`. Each facet is counted
+// with all filters applied except its own, so selecting "Action" still shows how
+// many movies the other genres would give.
+func GetMovieFacets(movies []*Movie.MovieDetails, filter *MovieFilter) *MovieFacets {
+	if filter == nil {
+		filter = NewMovieFilter()
+	}
+
+	var genres *movieFacetCounter = newMovieFacetCounter()
+	var decades *movieFacetCounter = newMovieFacetCounter()
+	var languages *movieFacetCounter = newMovieFacetCounter()
+	var mpaRatings *movieFacetCounter = newMovieFacetCounter()
+	var qualities *movieFacetCounter = newMovieFacetCounter()
+	var sources *movieFacetCounter = newMovieFacetCounter()
+
+	var withoutGenre MovieFilter = *filter
+	var withoutDecade MovieFilter = *filter
+	var withoutLanguage MovieFilter = *filter
+	var withoutMPARating MovieFilter = *filter
+	var withoutQuality MovieFilter = *filter
+	var withoutSource MovieFilter = *filter
+
+	withoutGenre.Genre = ""
+	withoutDecade.Decade = 0
+	withoutLanguage.Language = ""
+	withoutMPARating.MPARating = ""
+	withoutQuality.Quality = ""
+	withoutSource.Source = ""
+
+	for _, details := range movies {
+		if withoutGenre.Matches(details) {
+			genres.Add(details.Genres)
+		}
+
+		if withoutDecade.Matches(details) {
+			if decade := GetMovieDecade(details); decade > 0 {
+				decades.Add([]string{fmt.Sprintf("%.0f", decade)})
+			}
+		}
+
+		if withoutLanguage.Matches(details) {
+			languages.Add([]string{details.Language})
+		}
+
+		if withoutMPARating.Matches(details) {
+			mpaRatings.Add([]string{details.MPARating})
+		}
+
+		if withoutQuality.Matches(details) {
+			qualities.Add(getMovieQualities(details))
+		}
+
+		if withoutSource.Matches(details) {
+			sources.Add(getMovieSources(details))
+		}
+	}
+
+	var facets *MovieFacets = new(MovieFacets)
+
+	facets.Genres = genres.Sorted()
+	facets.Decades = decades.SortedByValue()
+	facets.Languages = languages.Sorted()
+	facets.MPARatings = mpaRatings.Sorted()
+	facets.Qualities = qualities.Sorted()
+	facets.Sources = sources.Sorted()
+
+	return facets
+}
@@ -0,0 +1,144 @@
+package Catalog
+
+import (
+	"GServer/Movie"
+	"math"
+	"strings"
+)
+
+type MovieFilter struct {
+	Genre     string
+	Quality   string
+	Language  string
+	MPARating string
+	Source    string
+
+	MinimumRating float64
+
+	YearFrom float64
+	YearTo   float64
+
+	// First year of the decade, 1990 for movies released from 1990 to 1999.
+	Decade float64
+}
+
+func NewMovieFilter() *MovieFilter {
+	var filter *MovieFilter = new(MovieFilter)
+
+	filter.Genre = ""
+	filter.Quality = ""
+	filter.Language = ""
+	filter.MPARating = ""
+	filter.Source = ""
+
+	filter.MinimumRating = 0
+
+	filter.YearFrom = 0
+	filter.YearTo = 0
+
+	filter.Decade = 0
+
+	return filter
+}
+
+func hasMovieGenre(details *Movie.MovieDetails, genre string) bool {
+	for _, g := range details.Genres {
+		if strings.EqualFold(g, genre) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetMovieDecade(details *Movie.MovieDetails) float64 {
+	if details.Year <= 0 {
+		return 0
+	}
+
+	return math.Floor(details.Year/10) * 10
+}
+
+func hasMovieQuality(details *Movie.MovieDetails, quality string) bool {
+	for _, torrent := range details.Torrents {
+		if strings.EqualFold(torrent.Quality, quality) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasMovieSource(details *Movie.MovieDetails, source string) bool {
+	if strings.EqualFold(details.Source, source) {
+		return true
+	}
+
+	for _, s := range details.Sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (this *MovieFilter) Matches(details *Movie.MovieDetails) bool {
+	if this == nil {
+		return true
+	}
+
+	if details == nil {
+		return false
+	}
+
+	if len(this.Genre) > 0 && !hasMovieGenre(details, this.Genre) {
+		return false
+	}
+
+	if len(this.Quality) > 0 && !hasMovieQuality(details, this.Quality) {
+		return false
+	}
+
+	if len(this.Language) > 0 && !strings.EqualFold(details.Language, this.Language) {
+		return false
+	}
+
+	if len(this.MPARating) > 0 && !strings.EqualFold(details.MPARating, this.MPARating) {
+		return false
+	}
+
+	if len(this.Source) > 0 && !hasMovieSource(details, this.Source) {
+		return false
+	}
+
+	if this.MinimumRating > 0 && details.Rating < this.MinimumRating {
+		return false
+	}
+
+	if this.YearFrom > 0 && details.Year < this.YearFrom {
+		return false
+	}
+
+	if this.YearTo > 0 && (details.Year == 0 || details.Year > this.YearTo) {
+		return false
+	}
+
+	if this.Decade > 0 && GetMovieDecade(details) != this.Decade {
+		return false
+	}
+
+	return true
+}
+
+func FilterMovies(movies []*Movie.MovieDetails, filter *MovieFilter) []*Movie.MovieDetails {
+	var result []*Movie.MovieDetails = []*Movie.MovieDetails{}
+
+	for _, details := range movies {
+		if filter.Matches(details) {
+			result = append(result, details)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,492 @@
+package Catalog
+
+import (
+	"GServer/Movie"
+	"html"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	SEARCH_FIELD_WEIGHT_TITLE           = 5.0
+	SEARCH_FIELD_WEIGHT_ALTERNATE_TITLE = 3.0
+	SEARCH_FIELD_WEIGHT_GENRE           = 2.0
+	SEARCH_FIELD_WEIGHT_CAST            = 2.0
+	SEARCH_FIELD_WEIGHT_DESCRIPTION     = 1.0
+
+	SEARCH_MATCH_WEIGHT_EXACT  = 1.0
+	SEARCH_MATCH_WEIGHT_PREFIX = 0.7
+	SEARCH_MATCH_WEIGHT_TYPO   = 0.5
+
+	// Multiplies the score of movies whose whole title is the query.
+	SEARCH_EXACT_TITLE_BOOST = 2.0
+
+	SEARCH_MINIMUM_PREFIX_LENGTH = 2
+	SEARCH_MAXIMUM_PREFIX_TERMS  = 50
+
+	SEARCH_BM25_K1 = 1.2
+	SEARCH_BM25_B  = 0.75
+
+	SEARCH_HIGHLIGHT_CONTEXT_WORDS = 12
+)
+
+type SearchResult struct {
+	Movie *Movie.MovieDetails
+
+	Score float64
+
+	// Matched words of the title and descriptions wrapped in <em>, the rest HTML escaped.
+	Highlights map[string]string
+}
+
+type searchDocument struct {
+	Terms  map[string]float64
+	Length float64
+}
+
+var searchDocuments []*searchDocument = nil
+var searchPostings map[string]map[int]float64 = nil
+var searchTotalLength float64 = 0
+
+var searchSortedTerms []string = nil
+var searchTermsByLength map[int][]string = nil
+var searchSortedTermsDirty bool = false
+
+// Searches only hold the catalog's read lock, this keeps concurrent searches from
+// rebuilding the term lists together.
+var searchTermsMutex sync.Mutex = sync.Mutex{}
+
+var searchWordExpression *regexp.Regexp = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+func addSearchField(document *searchDocument, text string, weight float64) {
+	for _, token := range Movie.SplitSearchTokens(text) {
+		document.Terms[token] += weight
+		document.Length += 1
+	}
+}
+
+func newSearchDocument(details *Movie.MovieDetails) *searchDocument {
+	var document *searchDocument = new(searchDocument)
+
+	document.Terms = map[string]float64{}
+	document.Length = 0
+
+	addSearchField(document, details.CleanTitle, SEARCH_FIELD_WEIGHT_TITLE)
+
+	if details.Title != details.CleanTitle {
+		addSearchField(document, details.Title, SEARCH_FIELD_WEIGHT_ALTERNATE_TITLE)
+	}
+
+	addSearchField(document, details.TitleEnglish, SEARCH_FIELD_WEIGHT_ALTERNATE_TITLE)
+
+	for _, genre := range details.Genres {
+		addSearchField(document, genre, SEARCH_FIELD_WEIGHT_GENRE)
+	}
+
+	for _, member := range details.Cast {
+		addSearchField(document, member.Name, SEARCH_FIELD_WEIGHT_CAST)
+		addSearchField(document, member.CharacterName, SEARCH_FIELD_WEIGHT_DESCRIPTION)
+	}
+
+	addSearchField(document, details.Summery, SEARCH_FIELD_WEIGHT_DESCRIPTION)
+
+	if details.DescriptionFull != details.Summery {
+		addSearchField(document, details.DescriptionFull, SEARCH_FIELD_WEIGHT_DESCRIPTION)
+	}
+
+	if details.Synopsis != details.DescriptionFull {
+		addSearchField(document, details.Synopsis, SEARCH_FIELD_WEIGHT_DESCRIPTION)
+	}
+
+	return document
+}
+
+// Indexes the movie stored at `index`, replacing its previous document. Must be
+// called with the catalog locked.
+func indexMovie(index int, details *Movie.MovieDetails) {
+	for len(searchDocuments) <= index {
+		searchDocuments = append(searchDocuments, nil)
+	}
+
+	if previous := searchDocuments[index]; previous != nil {
+		for term := range previous.Terms {
+			delete(searchPostings[term], index)
+
+			if len(searchPostings[term]) < 1 {
+				delete(searchPostings, term)
+
+				searchSortedTermsDirty = true
+			}
+		}
+
+		searchTotalLength -= previous.Length
+	}
+
+	var document *searchDocument = newSearchDocument(details)
+
+	for term, weight := range document.Terms {
+		postings, exists := searchPostings[term]
+
+		if !exists {
+			postings = map[int]float64{}
+
+			searchPostings[term] = postings
+
+			searchSortedTermsDirty = true
+		}
+
+		postings[index] = weight
+	}
+
+	searchTotalLength += document.Length
+
+	searchDocuments[index] = document
+}
+
+// Returns the indexed terms sorted and grouped by their length in runes. Both are
+// rebuilt lazily on the first search after the index changed and never modified
+// afterwards, so callers can keep using them after the lock is released.
+func getSearchTerms() ([]string, map[int][]string) {
+	searchTermsMutex.Lock()
+	defer searchTermsMutex.Unlock()
+
+	if !searchSortedTermsDirty && searchSortedTerms != nil {
+		return searchSortedTerms, searchTermsByLength
+	}
+
+	var terms []string = make([]string, 0, len(searchPostings))
+	var termsByLength map[int][]string = map[int][]string{}
+
+	for term := range searchPostings {
+		terms = append(terms, term)
+	}
+
+	sort.Strings(terms)
+
+	for _, term := range terms {
+		var length int = utf8.RuneCountInString(term)
+
+		termsByLength[length] = append(termsByLength[length], term)
+	}
+
+	searchSortedTerms = terms
+	searchTermsByLength = termsByLength
+	searchSortedTermsDirty = false
+
+	return searchSortedTerms, searchTermsByLength
+}
+
+func getMaximumSearchTypoDistance(term string) int {
+	var length int = len([]rune(term))
+
+	if length < 4 {
+		return 0
+	}
+
+	if length < 8 {
+		return 1
+	}
+
+	return 2
+}
+
+func getBoundedEditDistance(a []rune, b []rune, limit int) int {
+	if abs := len(a) - len(b); abs > limit || -abs > limit {
+		return limit + 1
+	}
+
+	var previous []int = make([]int, len(b)+1)
+	var current []int = make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		var rowMinimum int = current[0]
+
+		for j := 1; j <= len(b); j++ {
+			var cost int = 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			rowMinimum = min(rowMinimum, current[j])
+		}
+
+		if rowMinimum > limit {
+			return limit + 1
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// Expands a query word to the indexed terms it matches with the weight of the match:
+// the word itself, words starting with it and words one or two typos away.
+func expandSearchTerm(word string, allowPrefix bool) map[string]float64 {
+	var expansions map[string]float64 = map[string]float64{}
+
+	if _, exists := searchPostings[word]; exists {
+		expansions[word] = SEARCH_MATCH_WEIGHT_EXACT
+	}
+
+	terms, termsByLength := getSearchTerms()
+
+	if allowPrefix && len(word) >= SEARCH_MINIMUM_PREFIX_LENGTH {
+		var start int = sort.SearchStrings(terms, word)
+
+		for i := start; i < len(terms) && i-start < SEARCH_MAXIMUM_PREFIX_TERMS && strings.HasPrefix(terms[i], word); i++ {
+			if _, exists := expansions[terms[i]]; !exists {
+				expansions[terms[i]] = SEARCH_MATCH_WEIGHT_PREFIX
+			}
+		}
+	}
+
+	var distance int = getMaximumSearchTypoDistance(word)
+
+	if distance < 1 {
+		return expansions
+	}
+
+	var wordRunes []rune = []rune(word)
+
+	// Terms more than `distance` runes longer or shorter can't be close enough.
+	for length := len(wordRunes) - distance; length <= len(wordRunes)+distance; length++ {
+		for _, term := range termsByLength[length] {
+			if _, exists := expansions[term]; exists {
+				continue
+			}
+
+			if getBoundedEditDistance(wordRunes, []rune(term), distance) <= distance {
+				expansions[term] = SEARCH_MATCH_WEIGHT_TYPO
+			}
+		}
+	}
+
+	return expansions
+}
+
+func getSearchTermScore(term string, index int, documentCount float64, averageLength float64) float64 {
+	var postings map[int]float64 = searchPostings[term]
+
+	var frequency float64 = postings[index]
+
+	if frequency <= 0 {
+		return 0
+	}
+
+	var documentFrequency float64 = float64(len(postings))
+	var idf float64 = math.Log(1 + (documentCount-documentFrequency+0.5)/(documentFrequency+0.5))
+
+	var length float64 = searchDocuments[index].Length
+
+	return idf * (frequency * (SEARCH_BM25_K1 + 1)) / (frequency + SEARCH_BM25_K1*(1-SEARCH_BM25_B+SEARCH_BM25_B*length/averageLength))
+}
+
+func highlightSearchText(text string, terms map[string]float64, contextWords int) string {
+	var locations [][]int = searchWordExpression.FindAllStringIndex(text, -1)
+
+	var first int = -1
+	var matched []bool = make([]bool, len(locations))
+
+	for i, location := range locations {
+		var tokens []string = Movie.SplitSearchTokens(text[location[0]:location[1]])
+
+		if len(tokens) < 1 {
+			continue
+		}
+
+		if _, exists := terms[tokens[0]]; exists {
+			matched[i] = true
+
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+
+	if first < 0 {
+		return ""
+	}
+
+	var startWord int = 0
+	var endWord int = len(locations)
+
+	if contextWords > 0 {
+		startWord = max(first-contextWords/2, 0)
+		endWord = min(startWord+contextWords*2, len(locations))
+	}
+
+	var builder strings.Builder
+
+	var position int = locations[startWord][0]
+
+	if startWord > 0 {
+		builder.WriteString("…")
+	}
+
+	for i := startWord; i < endWord; i++ {
+		var location []int = locations[i]
+
+		builder.WriteString(html.EscapeString(text[position:location[0]]))
+
+		if matched[i] {
+			builder.WriteString("<em>" + html.EscapeString(text[location[0]:location[1]]) + "</em>")
+		} else {
+			builder.WriteString(html.EscapeString(text[location[0]:location[1]]))
+		}
+
+		position = location[1]
+	}
+
+	if endWord < len(locations) {
+		builder.WriteString("…")
+	} else {
+		builder.WriteString(html.EscapeString(text[position:]))
+	}
+
+	return builder.String()
+}
+
+func getSearchHighlights(details *Movie.MovieDetails, terms map[string]float64) map[string]string {
+	var highlights map[string]string = map[string]string{}
+
+	var fields []struct {
+		Name         string
+		Text         string
+		ContextWords int
+	} = []struct {
+		Name         string
+		Text         string
+		ContextWords int
+	}{
+		{"Title", details.Title, 0},
+		{"TitleEnglish", details.TitleEnglish, 0},
+		{"Summery", details.Summery, SEARCH_HIGHLIGHT_CONTEXT_WORDS},
+		{"DescriptionFull", details.DescriptionFull, SEARCH_HIGHLIGHT_CONTEXT_WORDS},
+		{"Synopsis", details.Synopsis, SEARCH_HIGHLIGHT_CONTEXT_WORDS},
+	}
+
+	for _, field := range fields {
+		if highlight := highlightSearchText(field.Text, terms, field.ContextWords); len(highlight) > 0 {
+			highlights[field.Name] = highlight
+		}
+	}
+
+	for _, genre := range details.Genres {
+		if highlight := highlightSearchText(genre, terms, 0); len(highlight) > 0 {
+			highlights["Genres"] = highlight
+			break
+		}
+	}
+
+	for _, member := range details.Cast {
+		if highlight := highlightSearchText(member.Name, terms, 0); len(highlight) > 0 {
+			highlights["Cast"] = highlight
+			break
+		}
+	}
+
+	return highlights
+}
+
+// Searches the catalog for movies containing every word of `query`, the last word
+// may be a prefix and longer words tolerate typos. Results are ranked with BM25
+// over field weighted term frequencies and filtered with `filter`.
+func Search(query string, filter *MovieFilter) []*SearchResult {
+	var words []string = Movie.SplitSearchTokens(query)
+
+	if len(words) < 1 {
+		return []*SearchResult{}
+	}
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var documentCount float64 = float64(len(Movies))
+
+	if documentCount < 1 {
+		return []*SearchResult{}
+	}
+
+	var averageLength float64 = math.Max(searchTotalLength/documentCount, 1)
+
+	var scores map[int]float64 = nil
+	var matchedTerms map[string]float64 = map[string]float64{}
+
+	for i, word := range words {
+		var expansions map[string]float64 = expandSearchTerm(word, i == len(words)-1)
+		var wordScores map[int]float64 = map[int]float64{}
+
+		for term, weight := range expansions {
+			matchedTerms[term] = weight
+
+			for index := range searchPostings[term] {
+				var score float64 = weight * getSearchTermScore(term, index, documentCount, averageLength)
+
+				wordScores[index] = math.Max(wordScores[index], score)
+			}
+		}
+
+		if scores == nil {
+			scores = wordScores
+			continue
+		}
+
+		for index, score := range scores {
+			wordScore, exists := wordScores[index]
+
+			if !exists {
+				delete(scores, index)
+				continue
+			}
+
+			scores[index] = score + wordScore
+		}
+	}
+
+	var searchKey string = strings.Join(words, " ")
+
+	var results []*SearchResult = []*SearchResult{}
+
+	for index, score := range scores {
+		var details *Movie.MovieDetails = Movies[index]
+
+		if !filter.Matches(details) {
+			continue
+		}
+
+		if details.SearchKey == searchKey {
+			score *= SEARCH_EXACT_TITLE_BOOST
+		}
+
+		var result *SearchResult = new(SearchResult)
+
+		result.Movie = details
+		result.Score = score
+		result.Highlights = getSearchHighlights(details, matchedTerms)
+
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i int, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		return results[i].Movie.SortTitle < results[j].Movie.SortTitle
+	})
+
+	return results
+}
@@ -0,0 +1,207 @@
+package Catalog
+
+import (
+	"GServer/Events"
+	"GServer/Logger"
+	"GServer/Movie"
+	"context"
+	"sort"
+	"strings"
+)
+
+type TorrentReference struct {
+	MovieIndex int
+
+	MovieId                float64
+	MovieSpecialIdentifier string
+	MovieTitle             string
+	MovieYear              float64
+
+	Source string
+}
+
+type TorrentRecord struct {
+	Hash string
+
+	Torrent *Movie.MovieTorrentInfo
+
+	// Index of the movie the torrent is attached to, other movies referencing the
+	// same hash don't get their own copy.
+	OwnerIndex int
+
+	References []*TorrentReference
+
+	HasConflict bool
+}
+
+var Torrents map[string]*TorrentRecord = nil
+
+func GetTorrentHashKey(torrent *Movie.MovieTorrentInfo) string {
+	if torrent == nil {
+		return ""
+	}
+
+	return strings.ToLower(torrent.Hash)
+}
+
+func newTorrentReference(index int, details *Movie.MovieDetails, source string) *TorrentReference {
+	var reference *TorrentReference = new(TorrentReference)
+
+	reference.MovieIndex = index
+
+	reference.MovieId = details.Id
+	reference.MovieSpecialIdentifier = details.SpecialIdentifier
+	reference.MovieTitle = details.Title
+	reference.MovieYear = details.Year
+
+	reference.Source = source
+
+	return reference
+}
+
+func copyTorrentRecord(record *TorrentRecord) *TorrentRecord {
+	var copied *TorrentRecord = new(TorrentRecord)
+
+	*copied = *record
+
+	copied.References = []*TorrentReference{}
+
+	for _, reference := range record.References {
+		var r TorrentReference = *reference
+
+		copied.References = append(copied.References, &r)
+	}
+
+	return copied
+}
+
+func isSameTorrentTitle(record *TorrentRecord, index int) bool {
+	var ownerKey *Movie.MovieMatchKey = movieMatchKeys[record.OwnerIndex]
+	var otherKey *Movie.MovieMatchKey = movieMatchKeys[index]
+
+	if ownerKey == nil || otherKey == nil {
+		return true
+	}
+
+	return ownerKey.Title == otherKey.Title || Movie.GetTitleSimilarity(ownerKey.Title, otherKey.Title) >= Movie.MOVIE_MATCH_MINIMUM_TITLE_SIMILARITY
+}
+
+// Registers the torrents of the movie stored at `index` and drops the ones already
+// attached to another movie, so every info-hash appears once in the catalog. Must
+// be called with the catalog locked.
+func registerMovieTorrents(ctx context.Context, index int, details *Movie.MovieDetails) {
+	var torrents []*Movie.MovieTorrentInfo = []*Movie.MovieTorrentInfo{}
+	var seen map[string]bool = map[string]bool{}
+
+	for _, torrent := range details.Torrents {
+		var hash string = GetTorrentHashKey(torrent)
+
+		if len(hash) < 1 {
+			torrents = append(torrents, torrent)
+			continue
+		}
+
+		if seen[hash] {
+			continue
+		}
+
+		seen[hash] = true
+
+		var source string = torrent.Source
+
+		if len(source) < 1 {
+			source = details.Source
+		}
+
+		record, exists := Torrents[hash]
+
+		if !exists {
+			record = new(TorrentRecord)
+
+			record.Hash = hash
+			record.Torrent = torrent
+			record.OwnerIndex = index
+			record.References = []*TorrentReference{}
+			record.HasConflict = false
+
+			Torrents[hash] = record
+
+			Events.Publish(Events.EVENT_TYPE_TORRENT_ADDED, source, Events.EventData{
+				"Hash":       hash,
+				"URL":        torrent.URL,
+				"Quality":    torrent.Quality,
+				"Type":       torrent.Type,
+				"Size":       torrent.Size,
+				"MovieSlug":  details.Slug,
+				"MovieTitle": details.Title,
+				"MovieYear":  details.Year,
+			})
+		}
+
+		var referenced bool = false
+
+		for i, reference := range record.References {
+			if reference.MovieIndex == index && reference.Source == source {
+				record.References[i] = newTorrentReference(index, details, source)
+				referenced = true
+				break
+			}
+		}
+
+		if !referenced {
+			record.References = append(record.References, newTorrentReference(index, details, source))
+		}
+
+		if record.OwnerIndex == index {
+			torrents = append(torrents, torrent)
+			continue
+		}
+
+		if !record.HasConflict && !isSameTorrentTitle(record, index) {
+			record.HasConflict = true
+
+			Logger.WARN_CONTEXT(ctx, "Torrent info-hash is referenced by different movies.", "hash", hash, "owner_title", Movies[record.OwnerIndex].Title, "title", details.Title)
+		}
+	}
+
+	details.Torrents = torrents
+}
+
+func GetTorrentRecord(hash string) *TorrentRecord {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	record, exists := Torrents[strings.ToLower(hash)]
+
+	if !exists {
+		return nil
+	}
+
+	return copyTorrentRecord(record)
+}
+
+func GetTorrentConflicts() []*TorrentRecord {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	var conflicts []*TorrentRecord = []*TorrentRecord{}
+
+	for _, record := range Torrents {
+		if record.HasConflict {
+			conflicts = append(conflicts, copyTorrentRecord(record))
+		}
+	}
+
+	sort.Slice(conflicts, func(i int, j int) bool {
+		return conflicts[i].Hash < conflicts[j].Hash
+	})
+
+	return conflicts
+}
+
+func GetTorrentCount() int {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return len(Torrents)
+}
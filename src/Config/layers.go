@@ -0,0 +1,312 @@
+package Config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ENVIRONMENT_VARIABLE_PREFIX = "GSERVER_"
+
+	// Separates nested keys in environment variable names, keys contain single underscores.
+	ENVIRONMENT_VARIABLE_SEPARATOR = "__"
+
+	CONFIG_SOURCE_DEFAULT     = "default"
+	CONFIG_SOURCE_FILE        = "file"
+	CONFIG_SOURCE_ENVIRONMENT = "env"
+	CONFIG_SOURCE_FLAG        = "flag"
+)
+
+// A setting that can be overridden, every leaf of the Config struct is one.
+type ConfigOption struct {
+	// Dot separated json keys, like "http_server.read_timeout".
+	Path string
+
+	EnvironmentVariable string
+	Flag                string
+
+	Type reflect.Type
+}
+
+type configOverride struct {
+	Option *ConfigOption
+	Value  string
+}
+
+var Options []*ConfigOption = getConfigOptions(reflect.TypeOf(Config{}), "")
+
+// Config file chosen with --config, the one next to the executable when empty.
+var FilePath string = ""
+
+// Set by --print-config, the application prints the effective config and exits.
+var PrintConfig bool = false
+
+// Where each option's effective value came from, like "default", "file /etc/gserver.json",
+// "env GSERVER_HTTP_HOST_ADDRESS" or "flag --http_host_address".
+var Sources map[string]string = map[string]string{}
+
+var flagOverrides []*configOverride = []*configOverride{}
+
+var durationType reflect.Type = reflect.TypeOf(time.Duration(0))
+
+func getConfigOptions(configType reflect.Type, prefix string) []*ConfigOption {
+	var options []*ConfigOption = []*ConfigOption{}
+
+	for index := 0; index < configType.NumField(); index++ {
+		var field reflect.StructField = configType.Field(index)
+
+		var name string = strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if len(name) < 1 || name == "-" {
+			continue
+		}
+
+		var path string = prefix + name
+
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			options = append(options, getConfigOptions(field.Type, path+".")...)
+			continue
+		}
+
+		var option *ConfigOption = new(ConfigOption)
+
+		option.Path = path
+
+		option.EnvironmentVariable = ENVIRONMENT_VARIABLE_PREFIX + strings.ToUpper(strings.ReplaceAll(path, ".", ENVIRONMENT_VARIABLE_SEPARATOR))
+		option.Flag = path
+
+		option.Type = field.Type
+
+		options = append(options, option)
+	}
+
+	return options
+}
+
+// Converts `value` to the JSON value the option is stored as. Durations take Go
+// duration strings like "30s" or nanoseconds, lists and maps take JSON.
+func (this *ConfigOption) ParseValue(value string) (any, error) {
+	if this.Type == durationType {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return int64(duration), nil
+		}
+
+		return strconv.ParseInt(value, 10, 64)
+	}
+
+	switch this.Type.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	}
+
+	// Checked against the field type so mistakes point at the variable or flag.
+	if err := json.Unmarshal([]byte(value), reflect.New(this.Type).Interface()); err != nil {
+		return nil, err
+	}
+
+	var parsed any = nil
+
+	err := json.Unmarshal([]byte(value), &parsed)
+
+	return parsed, err
+}
+
+func (this *configOverride) Set(value string) error {
+	this.Value = value
+
+	flagOverrides = append(flagOverrides, this)
+
+	return nil
+}
+
+// Lets boolean options be set with a bare `--name`.
+func (this *configOverride) IsBoolFlag() bool {
+	return this.Option != nil && this.Option.Type.Kind() == reflect.Bool
+}
+
+func (this *configOverride) String() string {
+	return this.Value
+}
+
+// Parses the command line, `--config` and `--print-config` plus one flag per
+// option named after its path, like `--http_server.read_timeout=30s`.
+func ParseArguments(name string, arguments []string) error {
+	var flags *flag.FlagSet = flag.NewFlagSet(name, flag.ContinueOnError)
+
+	flags.StringVar(&FilePath, "config", "", "Config file to read instead of "+CONFIG_FILE_NAME+" next to the executable.")
+	flags.BoolVar(&PrintConfig, "print-config", false, "Print the effective config and where each value came from, then exit.")
+
+	flagOverrides = []*configOverride{}
+
+	for _, option := range Options {
+		flags.Var(&configOverride{Option: option, Value: ""}, option.Flag, "Overrides "+option.Path+", also set by "+option.EnvironmentVariable+".")
+	}
+
+	return flags.Parse(arguments)
+}
+
+func getConfigValue(data map[string]any, path string) (any, bool) {
+	var keys []string = strings.Split(path, ".")
+
+	var current any = data
+
+	for _, key := range keys {
+		values, ok := current.(map[string]any)
+
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = values[key]
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func setConfigValue(data map[string]any, path string, value any) {
+	var keys []string = strings.Split(path, ".")
+
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := data[key].(map[string]any)
+
+		if !ok {
+			child = map[string]any{}
+
+			data[key] = child
+		}
+
+		data = child
+	}
+
+	data[keys[len(keys)-1]] = value
+}
+
+// Overlays the options set in `layer` on `data` and records `source` for them.
+// Values of the wrong type are reported and skipped.
+func mergeConfigLayer(data map[string]any, layer map[string]any, source string, sources map[string]string, problems *ConfigProblems) {
+	for _, option := range Options {
+		value, exists := getConfigValue(layer, option.Path)
+
+		if !exists {
+			continue
+		}
+
+		if err := option.checkValue(value); err != nil {
+			problems.addError(option.Path, "%s in %s", err.Error(), source)
+			continue
+		}
+
+		setConfigValue(data, option.Path, value)
+
+		sources[option.Path] = source
+	}
+}
+
+func applyConfigOverride(data map[string]any, option *ConfigOption, value string, source string, sources map[string]string, problems *ConfigProblems) {
+	parsed, err := option.ParseValue(value)
+
+	if err != nil {
+		problems.addError(option.Path, "invalid value '%s' from %s, expected %s", value, source, option.getTypeName())
+		return
+	}
+
+	setConfigValue(data, option.Path, parsed)
+
+	sources[option.Path] = source
+}
+
+// Applies GSERVER_* environment variables and then command line flags on `data`.
+func applyConfigOverrides(data map[string]any, sources map[string]string, problems *ConfigProblems) {
+	for _, option := range Options {
+		value, exists := os.LookupEnv(option.EnvironmentVariable)
+
+		if !exists {
+			continue
+		}
+
+		applyConfigOverride(data, option, value, CONFIG_SOURCE_ENVIRONMENT+" "+option.EnvironmentVariable, sources, problems)
+	}
+
+	for _, override := range flagOverrides {
+		applyConfigOverride(data, override.Option, override.Value, CONFIG_SOURCE_FLAG+" --"+override.Option.Flag, sources, problems)
+	}
+}
+
+// Flattens `config` to its JSON values, as decoded by encoding/json.
+func getConfigValues(config *Config) (map[string]any, error) {
+	data, err := json.Marshal(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any = map[string]any{}
+
+	err = json.Unmarshal(data, &values)
+
+	return values, err
+}
+
+// Formats a value from getConfigValues, durations as Go durations.
+func (this *ConfigOption) formatValue(value any) string {
+	if number, ok := value.(float64); ok && this.Type == durationType {
+		return time.Duration(number).String()
+	}
+
+	encoded, _ := json.Marshal(value)
+
+	return string(encoded)
+}
+
+// Writes every option with its effective value and source, durations are shown
+// as Go durations.
+func WriteEffectiveConfig(writer io.Writer) error {
+	var config Config = Get()
+
+	values, err := getConfigValues(&config)
+
+	if err != nil {
+		return err
+	}
+
+	mainMutex.RLock()
+
+	var sources map[string]string = Sources
+
+	mainMutex.RUnlock()
+
+	for _, option := range Options {
+		value, _ := getConfigValue(values, option.Path)
+
+		var source string = sources[option.Path]
+
+		if len(source) < 1 {
+			source = CONFIG_SOURCE_DEFAULT
+		}
+
+		if _, err := fmt.Fprintf(writer, "%s = %s (%s)\n", option.Path, option.formatValue(value), source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
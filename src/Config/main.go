@@ -4,10 +4,12 @@ import (
 	"GServer/Defaults"
 	"GServer/Logger"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,7 +17,19 @@ const (
 	CONFIG_FILE_NAME = "crawler_config.json"
 
 	DEFAULT_CONFIG_JSON_DATA = `{
+	"config_version" : %d,
 	"http_host_address" : "%s",
+	"http_server" : {
+		"read_timeout" : %d,
+		"read_header_timeout" : %d,
+		"write_timeout" : %d,
+		"idle_timeout" : %d,
+		"shutdown_timeout" : %d,
+		"max_header_bytes" : %d,
+		"tls_certificate_file" : "",
+		"tls_key_file" : "",
+		"tls_reload_interval" : %d
+	},
 	"can_use_yts_service" : true,
 	"can_use_ia_service" : true,
 	"crawler" : {
@@ -56,8 +70,7 @@ const (
 		".m4v",
 		".wmv",
 		".flv",
-		".3gp",
-		".srt"
+		".3gp"
 	],
 	"main_torrent_file_extensions" : [
 		".mp4",
@@ -75,7 +88,56 @@ const (
 		".flv",
 		".3gp",
 		".mkv"
-	]
+	],
+	"subtitle_torrent_file_extensions" : [
+		".srt",
+		".ass",
+		".ssa",
+		".vtt",
+		".sub",
+		".idx"
+	],
+	"webhooks" : {
+		"max_threads" : %d,
+		"max_attempts" : %d,
+		"retry_backoff" : %d,
+		"maximum_retry_backoff" : %d,
+		"request_timeout" : %d,
+		"delivery_log_size" : %d,
+		"targets" : []
+	},
+	"api_keys" : {
+		"enabled" : true,
+		"keys_file" : "%s",
+		"default_quota_requests" : %d,
+		"default_quota_period" : %d,
+		"keys" : []
+	},
+	"logging" : {
+		"level" : "%s",
+		"format" : "%s",
+		"package_levels" : {},
+		"disable_console" : false,
+		"files" : []
+	},
+	"tracing" : {
+		"enabled" : false,
+		"exporter" : "%s",
+		"service_name" : "%s",
+		"otlp_endpoint" : "%s",
+		"otlp_headers" : {},
+		"file" : "%s",
+		"file_max_size" : %d,
+		"file_rotation_interval" : %d,
+		"file_max_backups" : %d,
+		"file_max_backup_age" : %d,
+		"file_compress" : true,
+		"sample_ratio" : %g
+	},
+	"reload" : {
+		"watch_file" : true,
+		"watch_interval" : %d
+	}
 }`
 )
 
@@ -114,9 +176,161 @@ type ConfigTasksExecutionDelay struct {
 	IA_TORRENT_PARSER  time.Duration `json:"IA_TORRENT_PARSER"`
 }
 
+type ConfigWebhookTarget struct {
+	URL string `json:"url"`
+
+	// Key of the HMAC-SHA256 signature sent in the "X-GServer-Signature" header.
+	Secret string `json:"secret"`
+
+	// Event types delivered to the target, "crawler.*" selects every crawler event.
+	Events []string `json:"events"`
+
+	Disabled bool `json:"disabled"`
+}
+
+type ConfigWebhooks struct {
+	MaxThreads  int `json:"max_threads"`
+	MaxAttempts int `json:"max_attempts"`
+
+	RetryBackoff        time.Duration `json:"retry_backoff"`
+	MaximumRetryBackoff time.Duration `json:"maximum_retry_backoff"`
+
+	RequestTimeout time.Duration `json:"request_timeout"`
+
+	DeliveryLogSize int `json:"delivery_log_size"`
+
+	Targets []ConfigWebhookTarget `json:"targets"`
+}
+
+type ConfigHttpServer struct {
+	ReadTimeout       time.Duration `json:"read_timeout"`
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout"`
+	WriteTimeout      time.Duration `json:"write_timeout"`
+	IdleTimeout       time.Duration `json:"idle_timeout"`
+
+	// Longest wait for in-flight requests to finish when the server stops.
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+
+	MaxHeaderBytes int `json:"max_header_bytes"`
+
+	// The server speaks HTTPS when both files are set. They're checked for changes
+	// every `tls_reload_interval` so renewed certificates don't need a restart.
+	TLSCertificateFile string        `json:"tls_certificate_file"`
+	TLSKeyFile         string        `json:"tls_key_file"`
+	TLSReloadInterval  time.Duration `json:"tls_reload_interval"`
+}
+
+type ConfigApiKey struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+
+	// Hex encoded SHA-256 of the key, the key itself is never stored.
+	Hash string `json:"hash"`
+
+	// "catalog:read", "webhooks" or "admin", admin grants every scope.
+	Scopes []string `json:"scopes"`
+
+	// Requests allowed per quota period, 0 uses the default quota and -1 disables it.
+	QuotaRequests int           `json:"quota_requests"`
+	QuotaPeriod   time.Duration `json:"quota_period"`
+
+	Disabled bool `json:"disabled"`
+}
+
+type ConfigApiKeys struct {
+	// Without it the API is open to anyone who can reach it.
+	Enabled bool `json:"enabled"`
+
+	// Keys issued through the admin endpoints, relative to the application directory.
+	KeysFile string `json:"keys_file"`
+
+	DefaultQuotaRequests int           `json:"default_quota_requests"`
+	DefaultQuotaPeriod   time.Duration `json:"default_quota_period"`
+
+	Keys []ConfigApiKey `json:"keys"`
+}
+
+type ConfigLogFile struct {
+	// Relative paths are resolved against the application directory.
+	Path string `json:"path"`
+
+	// Format and minimum level of this file, empty values follow the console.
+	Format string `json:"format"`
+	Level  string `json:"level"`
+
+	// The file is rotated past `max_size` bytes and at every `rotation_interval`
+	// boundary, rotated files are kept up to `max_backups` files and `max_backup_age`.
+	// 0 uses the default and -1 turns the rule off.
+	MaxSize          int64         `json:"max_size"`
+	RotationInterval time.Duration `json:"rotation_interval"`
+	MaxBackups       int           `json:"max_backups"`
+	MaxBackupAge     time.Duration `json:"max_backup_age"`
+
+	// Gzips rotated files.
+	Compress bool `json:"compress"`
+
+	Disabled bool `json:"disabled"`
+}
+
+type ConfigLogging struct {
+	// "debug", "info", "warn" or "error".
+	Level string `json:"level"`
+
+	// "text" or "json".
+	Format string `json:"format"`
+
+	// Overrides `level` for records logged from a package, keyed by package name like "Crawler".
+	PackageLevels map[string]string `json:"package_levels"`
+
+	// Stops writing to stdout, for servers logging to files only.
+	DisableConsole bool `json:"disable_console"`
+
+	Files []ConfigLogFile `json:"files"`
+}
+
+type ConfigTracing struct {
+	Enabled bool `json:"enabled"`
+
+	// "otlp" sends spans to `otlp_endpoint` over OTLP/HTTP, "file" writes them to
+	// `file` as JSON lines for offline inspection.
+	Exporter string `json:"exporter"`
+
+	ServiceName string `json:"service_name"`
+
+	// Full URL of the collector's trace endpoint, like "http://localhost:4318/v1/traces".
+	OtlpEndpoint string            `json:"otlp_endpoint"`
+	OtlpHeaders  map[string]string `json:"otlp_headers"`
+
+	// Relative paths are resolved against the application directory.
+	File string `json:"file"`
+
+	// Rotation of `file`, same rules as the rotation of log files.
+	FileMaxSize          int64         `json:"file_max_size"`
+	FileRotationInterval time.Duration `json:"file_rotation_interval"`
+	FileMaxBackups       int           `json:"file_max_backups"`
+	FileMaxBackupAge     time.Duration `json:"file_max_backup_age"`
+	FileCompress         bool          `json:"file_compress"`
+
+	// Share of crawl pages traced, between 0 and 1.
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+type ConfigReload struct {
+	// Reloads the config when the file changes, SIGHUP reloads it either way.
+	WatchFile bool `json:"watch_file"`
+
+	// How often the file is checked for changes.
+	WatchInterval time.Duration `json:"watch_interval"`
+}
+
 type Config struct {
+	// Layout version of the file, older files are migrated on start.
+	ConfigVersion int `json:"config_version"`
+
 	HttpHostAddress string `json:"http_host_address"`
 
+	HttpServer ConfigHttpServer `json:"http_server"`
+
 	CanUseYTSService             bool `json:"can_use_yts_service"`
 	CanUseInternetArchiveService bool `json:"can_use_ia_service"`
 
@@ -127,14 +341,48 @@ type Config struct {
 
 	ValidTorrentFileExtensions []string `json:"valid_torrent_file_extensions"`
 	MainTorrentFileExtensions  []string `json:"main_torrent_file_extensions"`
+
+	SubtitleTorrentFileExtensions []string `json:"subtitle_torrent_file_extensions"`
+
+	Webhooks ConfigWebhooks `json:"webhooks"`
+
+	ApiKeys ConfigApiKeys `json:"api_keys"`
+
+	Logging ConfigLogging `json:"logging"`
+
+	Tracing ConfigTracing `json:"tracing"`
+
+	Reload ConfigReload `json:"reload"`
 }
 
+// Replaced as a whole when the config is reloaded, read it through Get outside
+// of initialization.
 var Main Config = Config{}
 
+// Outcome of ReadConfig, reported by the health endpoints. LoadedAt also moves with every accepted Reload.
+var LoadError error = nil
+var LoadedAt time.Time = time.Time{}
+
+// Why the last Reload was rejected, nil once one succeeds. The running config is
+// still the one loaded before, so it doesn't fail the health checks.
+var ReloadError error = nil
+var ReloadErrorAt time.Time = time.Time{}
+
+// Guards `Main`, `Sources`, `Problems`, `LoadError`, `LoadedAt`, `ReloadError` and `ReloadErrorAt`.
+var mainMutex sync.RWMutex = sync.RWMutex{}
+
 func GetDefaultCondigJsonString() string {
 	return fmt.Sprintf(
 		DEFAULT_CONFIG_JSON_DATA,
+		CONFIG_VERSION,
 		Defaults.DEFAULT_HTTP_SERVER_HOST_ADDRESS,
+		Defaults.HTTP_SERVER_READ_TIMEOUT,
+		Defaults.HTTP_SERVER_READ_HEADER_TIMEOUT,
+		Defaults.HTTP_SERVER_WRITE_TIMEOUT,
+		Defaults.HTTP_SERVER_IDLE_TIMEOUT,
+		Defaults.HTTP_SERVER_SHUTDOWN_TIMEOUT,
+		Defaults.HTTP_SERVER_MAX_HEADER_BYTES,
+		Defaults.HTTP_SERVER_TLS_RELOAD_INTERVAL,
 		Defaults.CRAWLER_YTS_MOVIE_COUNT_PER_SEARCH,
 		Defaults.CRAWLER_INTERNET_ARCHIVE_MOVIE_COUNT_PER_SAERCH,
 		Defaults.TASKS_MAX_THREADS_HTTP_SERVER,
@@ -152,84 +400,190 @@ func GetDefaultCondigJsonString() string {
 		Defaults.TASKS_EXECUTION_DELAY_YTS_MOVIE_PARSER,
 		Defaults.TASKS_EXECUTION_DELAY_IA_MOVIE_PARSER,
 		Defaults.TASKS_EXECUTION_DELAY_YTS_TORRENT_PARSER,
-		Defaults.TASKS_EXECUTION_DELAY_MOVIE_CRAWLER_IA)
+		Defaults.TASKS_EXECUTION_DELAY_MOVIE_CRAWLER_IA,
+		Defaults.TASKS_MAX_THREADS_WEBHOOKS,
+		Defaults.WEBHOOKS_MAX_ATTEMPTS,
+		Defaults.WEBHOOKS_RETRY_BACKOFF,
+		Defaults.WEBHOOKS_MAXIMUM_RETRY_BACKOFF,
+		Defaults.WEBHOOKS_REQUEST_TIMEOUT,
+		Defaults.WEBHOOKS_DELIVERY_LOG_SIZE,
+		Defaults.API_KEYS_FILE_NAME,
+		Defaults.API_KEYS_DEFAULT_QUOTA_REQUESTS,
+		Defaults.API_KEYS_DEFAULT_QUOTA_PERIOD,
+		Defaults.LOGGING_LEVEL,
+		Defaults.LOGGING_FORMAT,
+		Defaults.TRACING_EXPORTER,
+		Defaults.TRACING_SERVICE_NAME,
+		Defaults.TRACING_OTLP_ENDPOINT,
+		Defaults.TRACING_FILE_NAME,
+		Defaults.TRACING_FILE_MAX_SIZE,
+		Defaults.TRACING_FILE_ROTATION_INTERVAL,
+		Defaults.TRACING_FILE_MAX_BACKUPS,
+		Defaults.TRACING_FILE_MAX_BACKUP_AGE,
+		Defaults.TRACING_SAMPLE_RATIO,
+		Defaults.CONFIG_RELOAD_WATCH_INTERVAL)
 }
 
-func WriteConfig() {
+// Resolves `name` against the directory of the executable, where the config lives.
+func GetApplicationFilePath(name string) (string, error) {
+	if path.IsAbs(name) {
+		return name, nil
+	}
+
 	exePath, err := os.Executable()
-	exePath = strings.ReplaceAll(exePath, "\\", "/")
 
 	if err != nil {
-		Logger.ERROR("Couldn't get application path to write config file. [Message: " + err.Error() + "]")
-		return
+		return "", err
 	}
 
-	applicationDir := path.Dir(exePath)
-
-	var configFilePath string = path.Join(applicationDir, CONFIG_FILE_NAME)
+	exePath = strings.ReplaceAll(exePath, "\\", "/")
 
-	if _, err := os.Stat(configFilePath); !os.IsNotExist(err) {
-		err := os.Remove(configFilePath)
+	return path.Join(path.Dir(exePath), name), nil
+}
 
-		if err != nil {
-			Logger.ERROR("Couldn't remove config file. [Message: " + err.Error() + "]")
-			return
-		}
+// Path of the config file, the one given with --config or CONFIG_FILE_NAME next
+// to the executable.
+func GetConfigFilePath() (string, error) {
+	if len(FilePath) > 0 {
+		return FilePath, nil
 	}
 
-	err = os.WriteFile(configFilePath, []byte(GetDefaultCondigJsonString()), 0644)
+	return GetApplicationFilePath(CONFIG_FILE_NAME)
+}
+
+// Writes the default config file when there isn't one, existing files are left alone.
+func WriteConfig() {
+	configFilePath, err := GetConfigFilePath()
 
 	if err != nil {
-		Logger.ERROR("Couldn't write config file. [Message: " + err.Error() + "]")
+		Logger.ERROR("Couldn't get application path to write config file.", "error", err)
+		return
 	}
-}
 
-func ReadConfig() {
-	exePath, err := os.Executable()
-	exePath = strings.ReplaceAll(exePath, "\\", "/")
+	file, err := os.OpenFile(configFilePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+
+	if os.IsExist(err) {
+		return
+	}
 
 	if err != nil {
-		Logger.ERROR("Couldn't get application path to read config file. [Message: " + err.Error() + "]")
+		Logger.ERROR("Couldn't write config file.", "path", configFilePath, "error", err)
 		return
 	}
 
-	applicationDir := path.Dir(exePath)
+	defer file.Close()
+
+	if _, err := file.WriteString(GetDefaultCondigJsonString()); err != nil {
+		Logger.ERROR("Couldn't write config file.", "path", configFilePath, "error", err)
+	}
+}
+
+// Returns a copy of `Main`, safe to use while the config is being reloaded.
+func Get() Config {
+	mainMutex.RLock()
+	defer mainMutex.RUnlock()
 
-	var configFilePath string = path.Join(applicationDir, CONFIG_FILE_NAME)
+	return Main
+}
 
-	if _, err := os.Stat(configFilePath); os.IsNotExist(err) {
-		err := json.Unmarshal([]byte(GetDefaultCondigJsonString()), &Main)
+// Returns when the config was last loaded and its fatal problems, if any.
+func GetLoadState() (time.Time, error) {
+	mainMutex.RLock()
+	defer mainMutex.RUnlock()
 
-		if err != nil {
-			Logger.ERROR("Couldn't parse default config json data. [Message: " + err.Error() + "]")
-			return
-		}
+	return LoadedAt, LoadError
+}
 
-		WriteConfig()
+// Returns when the last Reload was rejected and why, a nil error when it wasn't.
+func GetReloadState() (time.Time, error) {
+	mainMutex.RLock()
+	defer mainMutex.RUnlock()
 
-		return
+	return ReloadErrorAt, ReloadError
+}
+
+// Builds a config from the defaults, the config file, GSERVER_* environment variables
+// and command line flags, each layer overriding the ones before it. Returns where
+// each value came from and the problems found on the way and by Validate.
+func loadConfig() (Config, map[string]string, ConfigProblems) {
+	var config Config = Config{}
+
+	var sources map[string]string = map[string]string{}
+
+	var problems ConfigProblems = ConfigProblems{}
+
+	var data map[string]any = map[string]any{}
+
+	if err := json.Unmarshal([]byte(GetDefaultCondigJsonString()), &data); err != nil {
+		problems.addError("", "couldn't parse default config json data: %s", err.Error())
+
+		return config, sources, problems
 	}
 
-	data, err := os.ReadFile(configFilePath)
+	configFilePath, err := GetConfigFilePath()
 
 	if err != nil {
-		Logger.ERROR("Couldn't read config file. [Message: " + err.Error() + "]")
-		return
+		problems.addError("", "couldn't get application path to read config file: %s", err.Error())
+	} else if fileData, err := os.ReadFile(configFilePath); err != nil {
+		// Without --config the file is optional, the defaults are used.
+		if !os.IsNotExist(err) || len(FilePath) > 0 {
+			problems.addError("", "couldn't read config file: %s", err.Error())
+		}
+	} else {
+		var layer map[string]any = map[string]any{}
+
+		if err := json.Unmarshal(fileData, &layer); err != nil {
+			problems.addError("", "couldn't parse config file %s: %s", configFilePath, describeJsonError(fileData, err))
+		} else {
+			validateConfigFileVersion(layer, &problems)
+
+			findUnknownConfigKeys(layer, "", &problems)
+
+			mergeConfigLayer(data, layer, CONFIG_SOURCE_FILE+" "+configFilePath, sources, &problems)
+		}
 	}
 
-	err = json.Unmarshal(data, &Main)
+	applyConfigOverrides(data, sources, &problems)
+
+	encoded, err := json.Marshal(data)
+
+	if err == nil {
+		err = json.Unmarshal(encoded, &config)
+	}
 
 	if err != nil {
-		Logger.ERROR("Coudln't parse config file json data. [Message: " + err.Error() + "]")
+		problems.addError("", "couldn't parse config json data: %s", err.Error())
 	}
+
+	problems = append(problems, Validate(&config)...)
+
+	return config, sources, problems
+}
+
+// Loads `Main`, see loadConfig. Problems end up in `Problems`, fatal ones in `LoadError` too.
+func ReadConfig() {
+	config, sources, problems := loadConfig()
+
+	mainMutex.Lock()
+
+	Main = config
+	Sources = sources
+
+	Problems = problems
+	LoadError = problems.Err()
+	LoadedAt = time.Now()
+
+	mainMutex.Unlock()
 }
 
 func IsTorrentFileExtensionValid(extension string) bool {
-	if len(Main.ValidTorrentFileExtensions) < 1 {
+	var extensions []string = Get().ValidTorrentFileExtensions
+
+	if len(extensions) < 1 {
 		return true
 	}
 
-	for _, ext := range Main.ValidTorrentFileExtensions {
+	for _, ext := range extensions {
 		if ext == extension {
 			return true
 		}
@@ -239,11 +593,13 @@ func IsTorrentFileExtensionValid(extension string) bool {
 }
 
 func IsMainTorrentFileExtension(extension string) bool {
-	if len(Main.MainTorrentFileExtensions) < 1 {
+	var extensions []string = Get().MainTorrentFileExtensions
+
+	if len(extensions) < 1 {
 		return true
 	}
 
-	for _, ext := range Main.MainTorrentFileExtensions {
+	for _, ext := range extensions {
 		if ext == extension {
 			return true
 		}
@@ -252,16 +608,179 @@ func IsMainTorrentFileExtension(extension string) bool {
 	return false
 }
 
-func Initialize() {
+func IsSubtitleTorrentFileExtension(extension string) bool {
+	extension = strings.ToLower(extension)
+
+	for _, ext := range Get().SubtitleTorrentFileExtensions {
+		if ext == extension {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSameRotatingFile(file *Logger.RotatingFile, other *Logger.RotatingFile) bool {
+	return file.Path == other.Path &&
+		file.MaxSize == other.MaxSize &&
+		file.RotationInterval == other.RotationInterval &&
+		file.MaxBackups == other.MaxBackups &&
+		file.MaxBackupAge == other.MaxBackupAge &&
+		file.Compress == other.Compress
+}
+
+func newLogFileSink(settings ConfigLogFile, format string) (Logger.Sink, error) {
+	var sink Logger.Sink = Logger.Sink{}
+
+	filePath, err := GetApplicationFilePath(settings.Path)
+
+	if err != nil {
+		return sink, err
+	}
+
+	if len(settings.Format) > 0 {
+		format = settings.Format
+	}
+
+	if err := Logger.ValidateFormat(format); err != nil {
+		return sink, err
+	}
+
+	var level Logger.ENUM_LOG_LEVEL = Logger.LOG_LEVEL_DEBUG
+
+	if len(settings.Level) > 0 {
+		level, err = Logger.ParseLevel(settings.Level)
+
+		if err != nil {
+			return sink, err
+		}
+	}
+
+	if settings.MaxSize == 0 {
+		settings.MaxSize = Defaults.LOGGING_FILE_MAX_SIZE
+	}
+
+	if settings.RotationInterval == 0 {
+		settings.RotationInterval = Defaults.LOGGING_FILE_ROTATION_INTERVAL
+	}
+
+	if settings.MaxBackups == 0 {
+		settings.MaxBackups = Defaults.LOGGING_FILE_MAX_BACKUPS
+	}
+
+	if settings.MaxBackupAge == 0 {
+		settings.MaxBackupAge = Defaults.LOGGING_FILE_MAX_BACKUP_AGE
+	}
+
+	var writer *Logger.RotatingFile = Logger.NewRotatingFile(filePath, settings.MaxSize, settings.RotationInterval, settings.MaxBackups, settings.MaxBackupAge, settings.Compress)
+
+	// Keeps writing through the file already open with the same settings.
+	for _, current := range Logger.GetSinks() {
+		if file, ok := current.Writer.(*Logger.RotatingFile); ok && isSameRotatingFile(file, writer) {
+			writer = file
+			break
+		}
+	}
+
+	sink.Writer = writer
+
+	sink.Format = format
+	sink.Level = level
+
+	return sink, nil
+}
+
+func applyLoggingSettings() {
+	var settings ConfigLogging = Get().Logging
+
+	if len(settings.Level) < 1 {
+		settings.Level = Defaults.LOGGING_LEVEL
+	}
+
+	if len(settings.Format) < 1 {
+		settings.Format = Defaults.LOGGING_FORMAT
+	}
+
+	var errs []error = []error{}
+
+	if err := Logger.Configure(settings.Level, settings.PackageLevels); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := Logger.ValidateFormat(settings.Format); err != nil {
+		errs = append(errs, err)
+
+		settings.Format = Defaults.LOGGING_FORMAT
+	}
+
+	var sinks []Logger.Sink = []Logger.Sink{}
+
+	if !settings.DisableConsole {
+		sinks = append(sinks, Logger.NewConsoleSink(settings.Format, Logger.LOG_LEVEL_DEBUG))
+	}
+
+	for _, file := range settings.Files {
+		if file.Disabled {
+			continue
+		}
+
+		sink, err := newLogFileSink(file, settings.Format)
+
+		if err != nil {
+			errs = append(errs, errors.New("Log file '"+file.Path+"': "+err.Error()))
+			continue
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	// Errors shouldn't leave the server without any log output.
+	if len(sinks) < 1 {
+		sinks = append(sinks, Logger.NewConsoleSink(settings.Format, Logger.LOG_LEVEL_DEBUG))
+	}
+
+	Logger.SetSinks(sinks)
+
+	if err := errors.Join(errs...); err != nil {
+		Logger.WARN("Invalid logging settings, falling back to defaults where needed.", "error", err)
+	}
+}
+
+func Initialize() error {
 	Logger.INFO("Initializing config...")
 
+	// Leaves a default file to edit on first run.
+	if len(FilePath) < 1 {
+		WriteConfig()
+	}
+
+	if _, err := MigrateConfigFile(); err != nil {
+		Logger.WARN("Couldn't migrate config file.", "error", err)
+	}
+
 	ReadConfig()
 
+	Problems.Log()
+
+	if LoadError != nil {
+		return LoadError
+	}
+
+	applyLoggingSettings()
+
+	startWatching()
+
 	Logger.INFO("Config initialized.")
+
+	return nil
 }
 
 func Uninitialize() {
 	Logger.INFO("Uninitializing config...")
 
+	stopWatching()
+
 	Logger.INFO("Config uninitialized.")
+
+	Logger.CloseSinks()
 }
@@ -0,0 +1,444 @@
+package Config
+
+import (
+	"GServer/Logger"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Version of the config layout, raised with every migration.
+	CONFIG_VERSION = 3
+
+	CONFIG_VERSION_KEY = "config_version"
+
+	BACKUP_FILE_TIME_FORMAT = "2006-01-02T15-04-05"
+)
+
+type ConfigMigrationFunction func(data map[string]any) error
+
+// Upgrades a config file one version, working on its decoded JSON.
+type ConfigMigration struct {
+	// Version the file is at after the migration.
+	Version int
+
+	Description string
+
+	Migrate ConfigMigrationFunction
+}
+
+// Migrations in version order. Keys missing from a file are added with their
+// defaults after the migrations run, so migrations only deal with renamed, moved
+// or reinterpreted keys.
+var Migrations []ConfigMigration = []ConfigMigration{
+	{
+		Version:     1,
+		Description: "Files from before versioning, only gets config_version.",
+		Migrate: func(data map[string]any) error {
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "Subtitles are no longer valid torrent files, removes .srt from valid_torrent_file_extensions.",
+		Migrate: func(data map[string]any) error {
+			extensions, ok := data["valid_torrent_file_extensions"].([]any)
+
+			if !ok {
+				return nil
+			}
+
+			data["valid_torrent_file_extensions"] = slices.DeleteFunc(extensions, func(extension any) bool {
+				text, ok := extension.(string)
+
+				return ok && strings.EqualFold(text, ".srt")
+			})
+
+			return nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "API keys are on by default, keeps them off for files from before they existed.",
+		Migrate: func(data map[string]any) error {
+			if _, exists := data["api_keys"]; exists {
+				return nil
+			}
+
+			data["api_keys"] = map[string]any{"enabled": false}
+
+			return nil
+		},
+	},
+}
+
+// Decodes config JSON keeping numbers exact, durations don't fit in a float64 losslessly.
+func decodeConfigJson(data []byte) (map[string]any, error) {
+	var decoder *json.Decoder = json.NewDecoder(bytes.NewReader(data))
+
+	decoder.UseNumber()
+
+	var values map[string]any = map[string]any{}
+
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Returns the version a decoded config file is at, 0 when it has none.
+func getConfigFileVersion(data map[string]any) (int, error) {
+	value, exists := data[CONFIG_VERSION_KEY]
+
+	if !exists {
+		return 0, nil
+	}
+
+	var text string = fmt.Sprint(value)
+
+	version, err := strconv.Atoi(text)
+
+	if err != nil || version < 0 {
+		return 0, errors.New("Invalid config version '" + text + "'")
+	}
+
+	return version, nil
+}
+
+// Returns the key order of every object in config JSON by its path, objects in
+// arrays share the path of the array followed by "[]".
+func getConfigKeyOrder(data []byte) (map[string][]string, error) {
+	var decoder *json.Decoder = json.NewDecoder(bytes.NewReader(data))
+
+	var order map[string][]string = map[string][]string{}
+
+	var readValue func(path string) error
+
+	readValue = func(path string) error {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				key, err := decoder.Token()
+
+				if err != nil {
+					return err
+				}
+
+				var name string = fmt.Sprint(key)
+
+				if !slices.Contains(order[path], name) {
+					order[path] = append(order[path], name)
+				}
+
+				var childPath string = name
+
+				if len(path) > 0 {
+					childPath = path + "." + name
+				}
+
+				if err := readValue(childPath); err != nil {
+					return err
+				}
+			}
+
+			_, err = decoder.Token()
+
+			return err
+		case json.Delim('['):
+			for decoder.More() {
+				if err := readValue(path + "[]"); err != nil {
+					return err
+				}
+			}
+
+			_, err = decoder.Token()
+
+			return err
+		}
+
+		return nil
+	}
+
+	if err := readValue(""); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Returns the keys of `values` in the first order that lists them, keys no order
+// lists come last sorted.
+func getOrderedConfigKeys(values map[string]any, orders []map[string][]string, path string) []string {
+	var keys []string = []string{}
+
+	for _, order := range orders {
+		for _, key := range order[path] {
+			if _, exists := values[key]; exists && !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	var rest []string = []string{}
+
+	for key := range values {
+		if !slices.Contains(keys, key) {
+			rest = append(rest, key)
+		}
+	}
+
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+// Encodes decoded config JSON like json.MarshalIndent with tabs, but with object
+// keys in the order given by `orders` instead of sorted.
+func encodeConfigJson(buffer *bytes.Buffer, value any, orders []map[string][]string, path string, indent string) error {
+	switch value := value.(type) {
+	case map[string]any:
+		if len(value) < 1 {
+			buffer.WriteString("{}")
+			return nil
+		}
+
+		buffer.WriteString("{\n")
+
+		var keys []string = getOrderedConfigKeys(value, orders, path)
+
+		for index, key := range keys {
+			encodedKey, err := json.Marshal(key)
+
+			if err != nil {
+				return err
+			}
+
+			buffer.WriteString(indent + "\t")
+			buffer.Write(encodedKey)
+			buffer.WriteString(": ")
+
+			var childPath string = key
+
+			if len(path) > 0 {
+				childPath = path + "." + key
+			}
+
+			if err := encodeConfigJson(buffer, value[key], orders, childPath, indent+"\t"); err != nil {
+				return err
+			}
+
+			if index < len(keys)-1 {
+				buffer.WriteString(",")
+			}
+
+			buffer.WriteString("\n")
+		}
+
+		buffer.WriteString(indent + "}")
+
+		return nil
+	case []any:
+		if len(value) < 1 {
+			buffer.WriteString("[]")
+			return nil
+		}
+
+		buffer.WriteString("[\n")
+
+		for index, item := range value {
+			buffer.WriteString(indent + "\t")
+
+			if err := encodeConfigJson(buffer, item, orders, path+"[]", indent+"\t"); err != nil {
+				return err
+			}
+
+			if index < len(value)-1 {
+				buffer.WriteString(",")
+			}
+
+			buffer.WriteString("\n")
+		}
+
+		buffer.WriteString(indent + "]")
+
+		return nil
+	}
+
+	encoded, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	buffer.Write(encoded)
+
+	return nil
+}
+
+// Adds the keys of `defaults` missing from `data`, descending into objects present
+// in both. Returns the paths of the added keys.
+func addMissingConfigKeys(data map[string]any, defaults map[string]any, prefix string) []string {
+	var added []string = []string{}
+
+	for key, value := range defaults {
+		current, exists := data[key]
+
+		if !exists {
+			data[key] = value
+
+			added = append(added, prefix+key)
+			continue
+		}
+
+		currentValues, currentIsObject := current.(map[string]any)
+		defaultValues, defaultIsObject := value.(map[string]any)
+
+		if currentIsObject && defaultIsObject {
+			added = append(added, addMissingConfigKeys(currentValues, defaultValues, prefix+key+".")...)
+		}
+	}
+
+	return added
+}
+
+// Writes `data` to `filePath` through a temporary file so a crash can't leave
+// half a config behind.
+func replaceFile(filePath string, data []byte) error {
+	var mode os.FileMode = 0644
+
+	if info, err := os.Stat(filePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	var temporaryPath string = filePath + ".tmp"
+
+	if err := os.WriteFile(temporaryPath, data, mode); err != nil {
+		os.Remove(temporaryPath)
+		return err
+	}
+
+	if err := os.Rename(temporaryPath, filePath); err != nil {
+		os.Remove(temporaryPath)
+		return err
+	}
+
+	return nil
+}
+
+// Upgrades the config file to CONFIG_VERSION in place. Migrations newer than the
+// file run in order, keys it lacks are added with their defaults and every value
+// already set is kept. The previous file is saved next to it as
+// "<file>.v<version>-<time>.bak". Returns whether the file was changed.
+func MigrateConfigFile() (bool, error) {
+	configFilePath, err := GetConfigFilePath()
+
+	if err != nil {
+		return false, err
+	}
+
+	fileData, err := os.ReadFile(configFilePath)
+
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	data, err := decodeConfigJson(fileData)
+
+	if err != nil {
+		return false, errors.New("Couldn't parse config file: " + describeJsonError(fileData, err))
+	}
+
+	version, err := getConfigFileVersion(data)
+
+	if err != nil {
+		return false, err
+	}
+
+	fileOrder, err := getConfigKeyOrder(fileData)
+
+	if err != nil {
+		return false, err
+	}
+
+	if version > CONFIG_VERSION {
+		return false, fmt.Errorf("Config file is version %d, this server supports up to %d", version, CONFIG_VERSION)
+	}
+
+	for _, migration := range Migrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		if err := migration.Migrate(data); err != nil {
+			return false, fmt.Errorf("Migration to config version %d failed: %s", migration.Version, err.Error())
+		}
+
+		Logger.INFO("Migrated config file.", "path", configFilePath, "version", migration.Version, "migration", migration.Description)
+	}
+
+	var defaultsData []byte = []byte(GetDefaultCondigJsonString())
+
+	defaults, err := decodeConfigJson(defaultsData)
+
+	if err != nil {
+		return false, err
+	}
+
+	defaultsOrder, err := getConfigKeyOrder(defaultsData)
+
+	if err != nil {
+		return false, err
+	}
+
+	var added []string = addMissingConfigKeys(data, defaults, "")
+
+	sort.Strings(added)
+
+	if version == CONFIG_VERSION && len(added) < 1 {
+		return false, nil
+	}
+
+	data[CONFIG_VERSION_KEY] = CONFIG_VERSION
+
+	// Keeps the file's own key order, added keys follow in the order of the defaults.
+	var encoded bytes.Buffer
+
+	if err := encodeConfigJson(&encoded, data, []map[string][]string{fileOrder, defaultsOrder}, "", ""); err != nil {
+		return false, err
+	}
+
+	encoded.WriteString("\n")
+
+	var backupPath string = fmt.Sprintf("%s.v%d-%s.bak", configFilePath, version, time.Now().Format(BACKUP_FILE_TIME_FORMAT))
+
+	if err := os.WriteFile(backupPath, fileData, 0600); err != nil {
+		return false, errors.New("Couldn't write config backup: " + err.Error())
+	}
+
+	if err := replaceFile(configFilePath, encoded.Bytes()); err != nil {
+		return false, err
+	}
+
+	Logger.INFO("Config file upgraded.", "path", configFilePath, "previous_version", version, "version", CONFIG_VERSION, "added_keys", added, "backup", backupPath)
+
+	return true, nil
+}
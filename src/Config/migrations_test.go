@@ -0,0 +1,289 @@
+package Config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestAddMissingConfigKeys(t *testing.T) {
+	var tests []struct {
+		Name     string
+		Data     string
+		Defaults string
+
+		ExpectedData  string
+		ExpectedAdded []string
+	} = []struct {
+		Name     string
+		Data     string
+		Defaults string
+
+		ExpectedData  string
+		ExpectedAdded []string
+	}{
+		{
+			Name:          "nothing missing",
+			Data:          `{"a": 1, "b": {"c": 2}}`,
+			Defaults:      `{"a": 3, "b": {"c": 4}}`,
+			ExpectedData:  `{"a": 1, "b": {"c": 2}}`,
+			ExpectedAdded: []string{},
+		},
+		{
+			Name:          "top level keys",
+			Data:          `{"a": 1}`,
+			Defaults:      `{"a": 3, "b": "x", "c": [".mkv"]}`,
+			ExpectedData:  `{"a": 1, "b": "x", "c": [".mkv"]}`,
+			ExpectedAdded: []string{"b", "c"},
+		},
+		{
+			Name:          "nested keys",
+			Data:          `{"b": {"c": 2}}`,
+			Defaults:      `{"b": {"c": 4, "d": {"e": true}}}`,
+			ExpectedData:  `{"b": {"c": 2, "d": {"e": true}}}`,
+			ExpectedAdded: []string{"b.d"},
+		},
+		{
+			Name:          "existing value of another type is kept",
+			Data:          `{"b": "off"}`,
+			Defaults:      `{"b": {"c": 4}}`,
+			ExpectedData:  `{"b": "off"}`,
+			ExpectedAdded: []string{},
+		},
+		{
+			Name:          "empty arrays are kept",
+			Data:          `{"c": []}`,
+			Defaults:      `{"c": [".mkv"]}`,
+			ExpectedData:  `{"c": []}`,
+			ExpectedAdded: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			data, err := decodeConfigJson([]byte(test.Data))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defaults, err := decodeConfigJson([]byte(test.Defaults))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := decodeConfigJson([]byte(test.ExpectedData))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var added []string = addMissingConfigKeys(data, defaults, "")
+
+			sort.Strings(added)
+
+			if !reflect.DeepEqual(added, test.ExpectedAdded) {
+				t.Errorf("addMissingConfigKeys added %v, expected %v", added, test.ExpectedAdded)
+			}
+
+			if !reflect.DeepEqual(data, expected) {
+				t.Errorf("addMissingConfigKeys produced %v, expected %v", data, expected)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	var current string = GetDefaultCondigJsonString()
+
+	var tests []struct {
+		Name string
+		File string
+
+		ExpectedChanged bool
+		ExpectedError   bool
+
+		// Strings the migrated file contains, in this order.
+		ExpectedContent []string
+		Unexpected      []string
+	} = []struct {
+		Name string
+		File string
+
+		ExpectedChanged bool
+		ExpectedError   bool
+
+		ExpectedContent []string
+		Unexpected      []string
+	}{
+		{
+			Name:            "unversioned file",
+			File:            `{"http_host_address": ":9000", "valid_torrent_file_extensions": [".mkv", ".SRT", ".mp4"]}`,
+			ExpectedChanged: true,
+			ExpectedContent: []string{
+				`"http_host_address": ":9000"`,
+				`"valid_torrent_file_extensions": [`,
+				`".mkv",`,
+				`".mp4"`,
+				`"config_version": 3`,
+			},
+			Unexpected: []string{`.SRT`},
+		},
+		{
+			Name:            "keeps key order",
+			File:            `{"config_version": 1, "tasks_max_threads": {"YTS_MOVIE_PARSER": 7, "MOVIE_CRAWLER_YTS": 1}, "http_host_address": ":9000"}`,
+			ExpectedChanged: true,
+			ExpectedContent: []string{
+				`"config_version": 3`,
+				`"tasks_max_threads": {`,
+				`"YTS_MOVIE_PARSER": 7,`,
+				`"MOVIE_CRAWLER_YTS": 1,`,
+				`"http_host_address": ":9000"`,
+			},
+		},
+		{
+			Name:            "keeps API keys off",
+			File:            `{"config_version": 2, "http_host_address": ":9000"}`,
+			ExpectedChanged: true,
+			ExpectedContent: []string{
+				`"config_version": 3`,
+				`"api_keys": {`,
+				`"enabled": false,`,
+			},
+		},
+		{
+			Name:            "keeps API keys settings",
+			File:            `{"config_version": 2, "api_keys": {"enabled": true}}`,
+			ExpectedChanged: true,
+			ExpectedContent: []string{
+				`"config_version": 3`,
+				`"api_keys": {`,
+				`"enabled": true,`,
+			},
+		},
+		{
+			Name:            "current file",
+			File:            current,
+			ExpectedChanged: false,
+		},
+		{
+			Name:          "newer version",
+			File:          `{"config_version": 4}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "invalid version",
+			File:          `{"config_version": "two"}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "invalid JSON",
+			File:          `{"http_host_address": }`,
+			ExpectedError: true,
+		},
+	}
+
+	var previousFilePath string = FilePath
+
+	defer func() {
+		FilePath = previousFilePath
+	}()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var directory string = t.TempDir()
+
+			FilePath = filepath.Join(directory, CONFIG_FILE_NAME)
+
+			if err := os.WriteFile(FilePath, []byte(test.File), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			changed, err := MigrateConfigFile()
+
+			if test.ExpectedError {
+				if err == nil {
+					t.Fatal("MigrateConfigFile succeeded, expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if changed != test.ExpectedChanged {
+				t.Fatalf("MigrateConfigFile changed the file: %v, expected %v", changed, test.ExpectedChanged)
+			}
+
+			fileData, err := os.ReadFile(FilePath)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			backups, err := filepath.Glob(FilePath + ".v*.bak")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !changed {
+				if string(fileData) != test.File {
+					t.Error("MigrateConfigFile rewrote a file it reported unchanged")
+				}
+
+				if len(backups) > 0 {
+					t.Errorf("MigrateConfigFile wrote backups %v for an unchanged file", backups)
+				}
+
+				return
+			}
+
+			if len(backups) != 1 {
+				t.Fatalf("MigrateConfigFile wrote backups %v, expected one", backups)
+			}
+
+			backupData, err := os.ReadFile(backups[0])
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(backupData) != test.File {
+				t.Error("Backup doesn't hold the previous file")
+			}
+
+			var content string = string(fileData)
+			var offset int = 0
+
+			for _, expected := range test.ExpectedContent {
+				var index int = strings.Index(content[offset:], expected)
+
+				if index < 0 {
+					t.Fatalf("Migrated file doesn't contain %q after offset %d:\n%s", expected, offset, content)
+				}
+
+				offset += index + len(expected)
+			}
+
+			for _, unexpected := range test.Unexpected {
+				if strings.Contains(content, unexpected) {
+					t.Errorf("Migrated file contains %q:\n%s", unexpected, content)
+				}
+			}
+
+			// A second run finds nothing left to do.
+			changed, err = MigrateConfigFile()
+
+			if err != nil || changed {
+				t.Errorf("Second MigrateConfigFile run changed: %v, error: %v", changed, err)
+			}
+		})
+	}
+}
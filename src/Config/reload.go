@@ -0,0 +1,340 @@
+package Config
+
+import (
+	"GServer/Defaults"
+	"GServer/Logger"
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Called after a reload changed the config, with copies of the old and new config.
+type ReloadListener func(previous *Config, current *Config)
+
+type ConfigChange struct {
+	Path string
+
+	Previous string
+	Current  string
+}
+
+// Options Reload applies without a restart, a path covers the options under it.
+// Changes to any other option are logged and only take effect on the next start.
+var ReloadableOptions []string = []string{
+	"can_use_yts_service",
+	"can_use_ia_service",
+	"tasks_max_threads.MOVIE_CRAWLER_YTS",
+	"tasks_max_threads.MOVIE_CRAWLER_IA",
+	"tasks_max_threads.YTS_MOVIE_PARSER",
+	"tasks_max_threads.IA_MOVIE_PARSER",
+	"tasks_max_threads.YTS_TORRENT_PARSER",
+	"tasks_max_threads.IA_TORRENT_PARSER",
+	"tasks_execution_delay.MOVIE_CRAWLER_YTS",
+	"tasks_execution_delay.MOVIE_CRAWLER_IA",
+	"tasks_execution_delay.YTS_MOVIE_PARSER",
+	"tasks_execution_delay.IA_MOVIE_PARSER",
+	"tasks_execution_delay.YTS_TORRENT_PARSER",
+	"tasks_execution_delay.IA_TORRENT_PARSER",
+	"valid_torrent_file_extensions",
+	"main_torrent_file_extensions",
+	"subtitle_torrent_file_extensions",
+	"logging",
+}
+
+var reloadListeners []ReloadListener = []ReloadListener{}
+var reloadListenersMutex sync.Mutex = sync.Mutex{}
+
+// Keeps reloads from overlapping when a signal and a file change come together.
+var reloadMutex sync.Mutex = sync.Mutex{}
+
+var watchContextCancel context.CancelFunc = nil
+var watchGroup sync.WaitGroup = sync.WaitGroup{}
+
+type configFileState struct {
+	ModTime time.Time
+	Size    int64
+}
+
+func AddReloadListener(listener ReloadListener) {
+	reloadListenersMutex.Lock()
+	defer reloadListenersMutex.Unlock()
+
+	reloadListeners = append(reloadListeners, listener)
+}
+
+func IsReloadable(path string) bool {
+	for _, option := range ReloadableOptions {
+		if path == option || strings.HasPrefix(path, option+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the struct field at the dot separated json `path` under `value`.
+func getConfigField(value reflect.Value, path string) (reflect.Value, bool) {
+	for _, key := range strings.Split(path, ".") {
+		var found bool = false
+
+		for index := 0; index < value.NumField(); index++ {
+			if strings.Split(value.Type().Field(index).Tag.Get("json"), ",")[0] == key {
+				value = value.Field(index)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+
+	return value, true
+}
+
+// Returns `previous` with the reloadable options taken from `current`, so options
+// that need a restart keep reporting the values the application runs with.
+func getReloadedConfig(previous *Config, current *Config) Config {
+	var reloaded Config = *previous
+
+	for _, path := range ReloadableOptions {
+		target, ok := getConfigField(reflect.ValueOf(&reloaded).Elem(), path)
+
+		if !ok {
+			continue
+		}
+
+		source, _ := getConfigField(reflect.ValueOf(current).Elem(), path)
+
+		target.Set(source)
+	}
+
+	return reloaded
+}
+
+// Returns `previous` with the sources of the reloadable options taken from `current`.
+func getReloadedSources(previous map[string]string, current map[string]string) map[string]string {
+	var sources map[string]string = map[string]string{}
+
+	for _, option := range Options {
+		var table map[string]string = previous
+
+		if IsReloadable(option.Path) {
+			table = current
+		}
+
+		if source, exists := table[option.Path]; exists {
+			sources[option.Path] = source
+		}
+	}
+
+	return sources
+}
+
+// Returns the options whose values differ between `previous` and `current`.
+func Diff(previous *Config, current *Config) []ConfigChange {
+	var changes []ConfigChange = []ConfigChange{}
+
+	previousValues, err := getConfigValues(previous)
+
+	if err != nil {
+		return changes
+	}
+
+	currentValues, err := getConfigValues(current)
+
+	if err != nil {
+		return changes
+	}
+
+	for _, option := range Options {
+		previousValue, _ := getConfigValue(previousValues, option.Path)
+		currentValue, _ := getConfigValue(currentValues, option.Path)
+
+		var change ConfigChange = ConfigChange{
+			Path:     option.Path,
+			Previous: option.formatValue(previousValue),
+			Current:  option.formatValue(currentValue),
+		}
+
+		if change.Previous != change.Current {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// Reads the config again and applies it. A config with fatal problems is rejected
+// and the running one is kept.
+func Reload() error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	Logger.INFO("Reloading config...")
+
+	config, sources, problems := loadConfig()
+
+	problems.Log()
+
+	var previous Config = Get()
+	var reloaded Config = getReloadedConfig(&previous, &config)
+
+	var err error = problems.Err()
+
+	mainMutex.Lock()
+
+	if err == nil {
+		Main = reloaded
+		Sources = getReloadedSources(Sources, sources)
+		Problems = problems
+		LoadedAt = time.Now()
+		ReloadError = nil
+	} else {
+		ReloadError = err
+		ReloadErrorAt = time.Now()
+	}
+
+	mainMutex.Unlock()
+
+	if err != nil {
+		Logger.ERROR("Invalid config, keeping the running one.", "error", err)
+		return err
+	}
+
+	var changes []ConfigChange = Diff(&previous, &config)
+
+	if len(changes) < 1 {
+		Logger.INFO("Config reloaded, nothing changed.")
+		return nil
+	}
+
+	for _, change := range changes {
+		if IsReloadable(change.Path) {
+			Logger.INFO("Config changed.", "path", change.Path, "previous", change.Previous, "current", change.Current)
+		} else {
+			Logger.WARN("Config change needs a restart to take effect.", "path", change.Path, "previous", change.Previous, "current", change.Current)
+		}
+	}
+
+	if !reflect.DeepEqual(previous.Logging, reloaded.Logging) {
+		applyLoggingSettings()
+	}
+
+	reloadListenersMutex.Lock()
+
+	var listeners []ReloadListener = append([]ReloadListener{}, reloadListeners...)
+
+	reloadListenersMutex.Unlock()
+
+	for _, listener := range listeners {
+		listener(&previous, &reloaded)
+	}
+
+	Logger.INFO("Config reloaded.", "changes", len(changes))
+
+	return nil
+}
+
+func getConfigFileState() configFileState {
+	configFilePath, err := GetConfigFilePath()
+
+	if err != nil {
+		return configFileState{}
+	}
+
+	info, err := os.Stat(configFilePath)
+
+	if err != nil {
+		return configFileState{}
+	}
+
+	return configFileState{ModTime: info.ModTime(), Size: info.Size()}
+}
+
+// Reloads the config on SIGHUP and, when `interval` is positive, whenever the
+// config file changes.
+func watch(ctx context.Context, interval time.Duration) {
+	var hangup chan os.Signal = make(chan os.Signal, 1)
+
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var tick <-chan time.Time = nil
+
+	if interval > 0 {
+		var ticker *time.Ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	var lastState configFileState = getConfigFileState()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			Logger.INFO("Got SIGHUP.")
+
+			Reload()
+
+			lastState = getConfigFileState()
+		case <-tick:
+			var state configFileState = getConfigFileState()
+
+			if state.ModTime.Equal(lastState.ModTime) && state.Size == lastState.Size {
+				continue
+			}
+
+			lastState = state
+
+			Reload()
+		}
+	}
+}
+
+func startWatching() {
+	var settings ConfigReload = Get().Reload
+
+	var interval time.Duration = 0
+
+	if settings.WatchFile {
+		interval = settings.WatchInterval
+
+		if interval <= 0 {
+			interval = Defaults.CONFIG_RELOAD_WATCH_INTERVAL
+		}
+	}
+
+	var ctx context.Context = nil
+
+	ctx, watchContextCancel = context.WithCancel(context.Background())
+
+	watchGroup.Add(1)
+
+	go func() {
+		defer watchGroup.Done()
+
+		watch(ctx, interval)
+	}()
+}
+
+func stopWatching() {
+	if watchContextCancel == nil {
+		return
+	}
+
+	watchContextCancel()
+
+	watchGroup.Wait()
+
+	watchContextCancel = nil
+}
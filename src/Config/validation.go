@@ -0,0 +1,457 @@
+package Config
+
+import (
+	"GServer/Logger"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Task delays past it are most likely milliseconds or seconds written as nanoseconds.
+	MAXIMUM_SANE_TASK_DELAY = time.Hour
+
+	API_KEY_HASH_LENGTH = 64
+)
+
+// A problem found while loading or validating the config.
+type ConfigProblem struct {
+	// JSON path of the value, like "http_server.read_timeout" or "webhooks.targets[0].url".
+	// Empty for problems with the whole file.
+	Path string
+
+	Message string
+
+	// Fatal problems stop the server from starting, the rest are warnings.
+	Fatal bool
+}
+
+type ConfigProblems []ConfigProblem
+
+// Problems of the running config, found by ReadConfig or the last accepted Reload.
+var Problems ConfigProblems = ConfigProblems{}
+
+func (this ConfigProblem) String() string {
+	var severity string = "warning"
+
+	if this.Fatal {
+		severity = "error"
+	}
+
+	if len(this.Path) < 1 {
+		return severity + ": " + this.Message
+	}
+
+	return severity + ": " + this.Path + ": " + this.Message
+}
+
+func (this ConfigProblems) HasFatal() bool {
+	for _, problem := range this {
+		if problem.Fatal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the fatal problems as one error, nil without any.
+func (this ConfigProblems) Err() error {
+	var errs []error = []error{}
+
+	for _, problem := range this {
+		if problem.Fatal {
+			errs = append(errs, errors.New(problem.String()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (this *ConfigProblems) add(fatal bool, path string, format string, args ...any) {
+	*this = append(*this, ConfigProblem{Path: path, Message: fmt.Sprintf(format, args...), Fatal: fatal})
+}
+
+func (this *ConfigProblems) addError(path string, format string, args ...any) {
+	this.add(true, path, format, args...)
+}
+
+func (this *ConfigProblems) addWarning(path string, format string, args ...any) {
+	this.add(false, path, format, args...)
+}
+
+// Logs every problem, fatal ones as errors.
+func (this ConfigProblems) Log() {
+	for _, problem := range this {
+		if problem.Fatal {
+			Logger.ERROR("Invalid config.", "path", problem.Path, "error", problem.Message)
+		} else {
+			Logger.WARN("Suspicious config.", "path", problem.Path, "warning", problem.Message)
+		}
+	}
+}
+
+// Describes a JSON syntax error with the line and column it's at.
+func describeJsonError(data []byte, err error) string {
+	var syntaxError *json.SyntaxError
+
+	if !errors.As(err, &syntaxError) {
+		return err.Error()
+	}
+
+	var line int = 1
+	var column int = 1
+
+	for _, character := range data[:min(int(syntaxError.Offset), len(data))] {
+		if character == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return fmt.Sprintf("%s at line %d, column %d", syntaxError.Error(), line, column)
+}
+
+func getJsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	}
+
+	return "object"
+}
+
+func (this *ConfigOption) getTypeName() string {
+	if this.Type == durationType {
+		return "duration in nanoseconds"
+	}
+
+	switch this.Type.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	}
+
+	return "object"
+}
+
+// Checks `value` from a config layer fits the option, so type mistakes are
+// reported with their path instead of failing the whole file.
+func (this *ConfigOption) checkValue(value any) error {
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, reflect.New(this.Type).Interface()); err != nil {
+		var typeError *json.UnmarshalTypeError
+
+		if errors.As(err, &typeError) && len(typeError.Field) < 1 {
+			return errors.New("expected " + this.getTypeName() + ", got " + getJsonTypeName(value))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Reports keys in `layer` no option matches, mostly typos.
+func findUnknownConfigKeys(layer map[string]any, prefix string, problems *ConfigProblems) {
+	for key, value := range layer {
+		var path string = prefix + key
+
+		var known bool = false
+		var leaf bool = false
+
+		for _, option := range Options {
+			if option.Path == path {
+				known = true
+				leaf = true
+
+				break
+			}
+
+			if strings.HasPrefix(option.Path, path+".") {
+				known = true
+			}
+		}
+
+		if !known {
+			problems.addWarning(path, "unknown key, it's ignored")
+			continue
+		}
+
+		if values, ok := value.(map[string]any); ok && !leaf {
+			findUnknownConfigKeys(values, path+".", problems)
+		}
+	}
+}
+
+func validateConfigFileVersion(layer map[string]any, problems *ConfigProblems) {
+	version, err := getConfigFileVersion(layer)
+
+	if err != nil {
+		problems.addError(CONFIG_VERSION_KEY, "%s", err.Error())
+		return
+	}
+
+	if version > CONFIG_VERSION {
+		problems.addError(CONFIG_VERSION_KEY, "file is version %d, this server supports up to %d", version, CONFIG_VERSION)
+	} else if version < CONFIG_VERSION {
+		problems.addWarning(CONFIG_VERSION_KEY, "file is version %d, it's upgraded to %d when the server starts", version, CONFIG_VERSION)
+	}
+}
+
+func validateHostAddress(path string, address string, problems *ConfigProblems) {
+	_, port, err := net.SplitHostPort(address)
+
+	if err != nil {
+		problems.addError(path, "'%s' isn't a host address like \"localhost:3050\" or \":3050\": %s", address, err.Error())
+		return
+	}
+
+	number, err := strconv.Atoi(port)
+
+	if err != nil || number < 0 || number > 65535 {
+		problems.addError(path, "port '%s' must be a number between 0 and 65535", port)
+	}
+}
+
+func validateNotNegative[T int | int64 | time.Duration](path string, value T, problems *ConfigProblems) {
+	if value < 0 {
+		problems.addError(path, "must not be negative, got %v", value)
+	}
+}
+
+func validatePositive(path string, value int, problems *ConfigProblems) {
+	if value < 1 {
+		problems.addError(path, "must be at least 1, got %d", value)
+	}
+}
+
+// -1 turns a rule off and 0 uses its default, anything lower is a mistake.
+func validateLimit[T int | int64 | time.Duration](path string, value T, problems *ConfigProblems) {
+	if value < -1 {
+		problems.addError(path, "must be -1 to turn it off, 0 for the default or a positive value, got %v", value)
+	}
+}
+
+func validateTaskDelay(path string, delay time.Duration, problems *ConfigProblems) {
+	if delay < -1 {
+		problems.addError(path, "must be -1 to turn the delay off or a duration in nanoseconds, got %d", int64(delay))
+		return
+	}
+
+	if delay > MAXIMUM_SANE_TASK_DELAY {
+		problems.addWarning(path, "delay of %s between tasks, durations are in nanoseconds", delay.String())
+	}
+}
+
+func validateExtensions(path string, extensions []string, problems *ConfigProblems) {
+	for index, extension := range extensions {
+		var extensionPath string = fmt.Sprintf("%s[%d]", path, index)
+
+		if len(extension) < 2 || !strings.HasPrefix(extension, ".") {
+			problems.addError(extensionPath, "'%s' must start with a dot, like \".mp4\"", extension)
+		}
+	}
+}
+
+func validateUrl(path string, value string, problems *ConfigProblems) {
+	parsed, err := url.Parse(value)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) < 1 {
+		problems.addError(path, "'%s' must be an absolute http or https URL", value)
+	}
+}
+
+func validateLogLevel(path string, level string, problems *ConfigProblems) {
+	if len(level) < 1 {
+		return
+	}
+
+	if _, err := Logger.ParseLevel(level); err != nil {
+		problems.addError(path, "%s, use \"debug\", \"info\", \"warn\" or \"error\"", err.Error())
+	}
+}
+
+func validateLogFormat(path string, format string, problems *ConfigProblems) {
+	if len(format) < 1 {
+		return
+	}
+
+	if err := Logger.ValidateFormat(format); err != nil {
+		problems.addError(path, "%s, use \"text\" or \"json\"", err.Error())
+	}
+}
+
+// Checks the values of `config` make sense together.
+func Validate(config *Config) ConfigProblems {
+	var problems ConfigProblems = ConfigProblems{}
+
+	validateHostAddress("http_host_address", config.HttpHostAddress, &problems)
+
+	validateNotNegative("http_server.read_timeout", config.HttpServer.ReadTimeout, &problems)
+	validateNotNegative("http_server.read_header_timeout", config.HttpServer.ReadHeaderTimeout, &problems)
+	validateNotNegative("http_server.write_timeout", config.HttpServer.WriteTimeout, &problems)
+	validateNotNegative("http_server.idle_timeout", config.HttpServer.IdleTimeout, &problems)
+	validateNotNegative("http_server.shutdown_timeout", config.HttpServer.ShutdownTimeout, &problems)
+	validateNotNegative("http_server.max_header_bytes", config.HttpServer.MaxHeaderBytes, &problems)
+	validateNotNegative("http_server.tls_reload_interval", config.HttpServer.TLSReloadInterval, &problems)
+
+	if (len(config.HttpServer.TLSCertificateFile) > 0) != (len(config.HttpServer.TLSKeyFile) > 0) {
+		problems.addError("http_server.tls_key_file", "tls_certificate_file and tls_key_file must be set together")
+	}
+
+	validatePositive("crawler.yts_movie_count_per_search", config.Crawler.YTSMovieCountPerSearch, &problems)
+	validatePositive("crawler.ia_movie_count_per_search", config.Crawler.InternetArchiveMovieCountPerSearch, &problems)
+
+	var maxThreads reflect.Value = reflect.ValueOf(config.TasksMaxThreads)
+	var executionDelays reflect.Value = reflect.ValueOf(config.TasksExecutionDelay)
+
+	for index := 0; index < maxThreads.NumField(); index++ {
+		validateNotNegative("tasks_max_threads."+maxThreads.Type().Field(index).Name, int(maxThreads.Field(index).Int()), &problems)
+	}
+
+	for index := 0; index < executionDelays.NumField(); index++ {
+		validateTaskDelay("tasks_execution_delay."+executionDelays.Type().Field(index).Name, time.Duration(executionDelays.Field(index).Int()), &problems)
+	}
+
+	validateExtensions("valid_torrent_file_extensions", config.ValidTorrentFileExtensions, &problems)
+	validateExtensions("main_torrent_file_extensions", config.MainTorrentFileExtensions, &problems)
+	validateExtensions("subtitle_torrent_file_extensions", config.SubtitleTorrentFileExtensions, &problems)
+
+	validateNotNegative("webhooks.max_threads", config.Webhooks.MaxThreads, &problems)
+	validateNotNegative("webhooks.max_attempts", config.Webhooks.MaxAttempts, &problems)
+	validateNotNegative("webhooks.retry_backoff", config.Webhooks.RetryBackoff, &problems)
+	validateNotNegative("webhooks.maximum_retry_backoff", config.Webhooks.MaximumRetryBackoff, &problems)
+	validateNotNegative("webhooks.request_timeout", config.Webhooks.RequestTimeout, &problems)
+	validateNotNegative("webhooks.delivery_log_size", config.Webhooks.DeliveryLogSize, &problems)
+
+	if config.Webhooks.RetryBackoff > 0 && config.Webhooks.MaximumRetryBackoff > 0 && config.Webhooks.MaximumRetryBackoff < config.Webhooks.RetryBackoff {
+		problems.addWarning("webhooks.maximum_retry_backoff", "is shorter than retry_backoff, retries won't back off")
+	}
+
+	for index, target := range config.Webhooks.Targets {
+		var path string = fmt.Sprintf("webhooks.targets[%d]", index)
+
+		if target.Disabled {
+			continue
+		}
+
+		validateUrl(path+".url", target.URL, &problems)
+
+		if len(target.Events) < 1 {
+			problems.addWarning(path+".events", "no events selected, nothing is delivered to the target")
+		}
+	}
+
+	validateLimit("api_keys.default_quota_requests", config.ApiKeys.DefaultQuotaRequests, &problems)
+	validateNotNegative("api_keys.default_quota_period", config.ApiKeys.DefaultQuotaPeriod, &problems)
+
+	var keyIds map[string]bool = map[string]bool{}
+
+	for index, key := range config.ApiKeys.Keys {
+		var path string = fmt.Sprintf("api_keys.keys[%d]", index)
+
+		if len(key.Id) < 1 {
+			problems.addError(path+".id", "must be set")
+		} else if keyIds[key.Id] {
+			problems.addError(path+".id", "'%s' is used by another key", key.Id)
+		}
+
+		keyIds[key.Id] = true
+
+		if _, err := hex.DecodeString(key.Hash); err != nil || len(key.Hash) != API_KEY_HASH_LENGTH {
+			problems.addError(path+".hash", "must be the hex encoded SHA-256 of the key, %d characters", API_KEY_HASH_LENGTH)
+		}
+
+		if len(key.Scopes) < 1 {
+			problems.addWarning(path+".scopes", "no scopes granted, the key can't call any endpoint")
+		}
+
+		validateLimit(path+".quota_requests", key.QuotaRequests, &problems)
+		validateNotNegative(path+".quota_period", key.QuotaPeriod, &problems)
+	}
+
+	validateLogLevel("logging.level", config.Logging.Level, &problems)
+	validateLogFormat("logging.format", config.Logging.Format, &problems)
+
+	for name, level := range config.Logging.PackageLevels {
+		validateLogLevel("logging.package_levels."+name, level, &problems)
+	}
+
+	for index, file := range config.Logging.Files {
+		var path string = fmt.Sprintf("logging.files[%d]", index)
+
+		if file.Disabled {
+			continue
+		}
+
+		if len(file.Path) < 1 {
+			problems.addError(path+".path", "must be set")
+		}
+
+		validateLogLevel(path+".level", file.Level, &problems)
+		validateLogFormat(path+".format", file.Format, &problems)
+
+		validateLimit(path+".max_size", file.MaxSize, &problems)
+		validateLimit(path+".rotation_interval", file.RotationInterval, &problems)
+		validateLimit(path+".max_backups", file.MaxBackups, &problems)
+		validateLimit(path+".max_backup_age", file.MaxBackupAge, &problems)
+	}
+
+	if config.Logging.DisableConsole && len(config.Logging.Files) < 1 {
+		problems.addWarning("logging.disable_console", "no log files are set, logs go to the console anyway")
+	}
+
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		problems.addError("tracing.sample_ratio", "must be between 0 and 1, got %g", config.Tracing.SampleRatio)
+	}
+
+	if config.Tracing.Enabled {
+		switch strings.ToLower(config.Tracing.Exporter) {
+		case "", "otlp":
+			if len(config.Tracing.OtlpEndpoint) > 0 {
+				validateUrl("tracing.otlp_endpoint", config.Tracing.OtlpEndpoint, &problems)
+			}
+		case "file":
+			validateLimit("tracing.file_max_size", config.Tracing.FileMaxSize, &problems)
+			validateLimit("tracing.file_rotation_interval", config.Tracing.FileRotationInterval, &problems)
+			validateLimit("tracing.file_max_backups", config.Tracing.FileMaxBackups, &problems)
+			validateLimit("tracing.file_max_backup_age", config.Tracing.FileMaxBackupAge, &problems)
+		default:
+			problems.addError("tracing.exporter", "'%s' isn't an exporter, use \"otlp\" or \"file\"", config.Tracing.Exporter)
+		}
+	}
+
+	validateNotNegative("reload.watch_interval", config.Reload.WatchInterval, &problems)
+
+	return problems
+}
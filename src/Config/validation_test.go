@@ -0,0 +1,160 @@
+package Config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getDefaultConfig(t *testing.T) Config {
+	var config Config = Config{}
+
+	if err := json.Unmarshal([]byte(GetDefaultCondigJsonString()), &config); err != nil {
+		t.Fatalf("Couldn't parse the default config: %s", err.Error())
+	}
+
+	return config
+}
+
+func TestValidate(t *testing.T) {
+	var tests []struct {
+		Name   string
+		Change func(config *Config)
+
+		// Paths of the problems found, fatal ones prefixed with "error: " and the rest with "warning: ".
+		Expected []string
+	} = []struct {
+		Name   string
+		Change func(config *Config)
+
+		Expected []string
+	}{
+		{
+			Name:     "defaults",
+			Change:   func(config *Config) {},
+			Expected: []string{},
+		},
+		{
+			Name: "host address without port",
+			Change: func(config *Config) {
+				config.HttpHostAddress = "localhost"
+			},
+			Expected: []string{"error: http_host_address"},
+		},
+		{
+			Name: "port out of range",
+			Change: func(config *Config) {
+				config.HttpHostAddress = ":70000"
+			},
+			Expected: []string{"error: http_host_address"},
+		},
+		{
+			Name: "negative timeout",
+			Change: func(config *Config) {
+				config.HttpServer.ReadTimeout = -time.Second
+			},
+			Expected: []string{"error: http_server.read_timeout"},
+		},
+		{
+			Name: "certificate without key",
+			Change: func(config *Config) {
+				config.HttpServer.TLSCertificateFile = "server.crt"
+			},
+			Expected: []string{"error: http_server.tls_key_file"},
+		},
+		{
+			Name: "no movies per search",
+			Change: func(config *Config) {
+				config.Crawler.YTSMovieCountPerSearch = 0
+			},
+			Expected: []string{"error: crawler.yts_movie_count_per_search"},
+		},
+		{
+			Name: "task delays",
+			Change: func(config *Config) {
+				config.TasksExecutionDelay.YTS_MOVIE_PARSER = -2
+				config.TasksExecutionDelay.IA_MOVIE_PARSER = time.Hour * 2
+			},
+			Expected: []string{"error: tasks_execution_delay.YTS_MOVIE_PARSER", "warning: tasks_execution_delay.IA_MOVIE_PARSER"},
+		},
+		{
+			Name: "extension without dot",
+			Change: func(config *Config) {
+				config.ValidTorrentFileExtensions = []string{".mkv", "mp4"}
+			},
+			Expected: []string{"error: valid_torrent_file_extensions[1]"},
+		},
+		{
+			Name: "webhook targets",
+			Change: func(config *Config) {
+				config.Webhooks.Targets = []ConfigWebhookTarget{
+					{URL: "ftp://example.com", Events: []string{"movie.added"}},
+					{URL: "https://example.com/hook"},
+					{URL: "not a url", Disabled: true},
+				}
+			},
+			Expected: []string{"error: webhooks.targets[0].url", "warning: webhooks.targets[1].events"},
+		},
+		{
+			Name: "api keys",
+			Change: func(config *Config) {
+				config.ApiKeys.Keys = []ConfigApiKey{
+					{Id: "a", Hash: strings.Repeat("ab", 32), Scopes: []string{"admin"}},
+					{Id: "a", Hash: "1234", Scopes: []string{"admin"}},
+					{Id: "", Hash: strings.Repeat("0", 64)},
+				}
+			},
+			Expected: []string{"error: api_keys.keys[1].id", "error: api_keys.keys[1].hash", "error: api_keys.keys[2].id", "warning: api_keys.keys[2].scopes"},
+		},
+		{
+			Name: "log settings",
+			Change: func(config *Config) {
+				config.Logging.Level = "loud"
+				config.Logging.Files = []ConfigLogFile{{Path: "", Format: "xml", MaxSize: -2}}
+			},
+			Expected: []string{"error: logging.level", "error: logging.files[0].path", "error: logging.files[0].format", "error: logging.files[0].max_size"},
+		},
+		{
+			Name: "tracing",
+			Change: func(config *Config) {
+				config.Tracing.Enabled = true
+				config.Tracing.Exporter = "zipkin"
+				config.Tracing.SampleRatio = 2
+			},
+			Expected: []string{"error: tracing.sample_ratio", "error: tracing.exporter"},
+		},
+		{
+			Name: "reload interval",
+			Change: func(config *Config) {
+				config.Reload.WatchInterval = -time.Second
+			},
+			Expected: []string{"error: reload.watch_interval"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var config Config = getDefaultConfig(t)
+
+			test.Change(&config)
+
+			var found []string = []string{}
+
+			for _, problem := range Validate(&config) {
+				var severity string = "warning: "
+
+				if problem.Fatal {
+					severity = "error: "
+				}
+
+				found = append(found, severity+problem.Path)
+			}
+
+			if !reflect.DeepEqual(found, test.Expected) {
+				t.Errorf("Validate found %v, expected %v", found, test.Expected)
+			}
+		})
+	}
+}
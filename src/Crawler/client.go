@@ -1,13 +1,32 @@
 package Crawler
 
 import (
+	"GServer/Catalog"
+	"GServer/Events"
 	"GServer/Logger"
+	"GServer/Metrics"
 	"GServer/Movie"
+	"GServer/TaskManager"
+	"GServer/Tracing"
 	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	CRAWLER_STATE_IDLE     = "idle"
+	CRAWLER_STATE_RUNNING  = "running"
+	CRAWLER_STATE_FINISHED = "finished"
+	CRAWLER_STATE_STOPPED  = "stopped"
+	CRAWLER_STATE_FAILED   = "failed"
+
+	CRAWLER_STOP_TIMEOUT = time.Second * 30
 )
 
-type SearchResultFunction func(*Client) []*Movie.MovieDetails
-type ServiceTotalLengthFunction func(*Client) float64
+type SearchResultFunction func(context.Context, *Client) ([]*Movie.MovieDetails, error)
+type ServiceTotalLengthFunction func(context.Context, *Client) float64
 
 type Client struct {
 	Name string
@@ -21,33 +40,344 @@ type Client struct {
 
 	Started bool
 
+	State     string
+	LastError string
+
+	LastPageTime time.Time
+
+	PageDelay time.Duration
+
+	Tasks *TaskManager.TaskManager
+
 	GetSearchResult    SearchResultFunction
 	GetTotalMovieCount ServiceTotalLengthFunction
 
 	Context context.Context
 
 	ServiceClient interface{}
+
+	// Cancels the running crawl, set while one runs.
+	runCancel context.CancelFunc
+
+	// Closed once the running crawl returned.
+	runDone chan struct{}
+
+	// Guards the fields above that change while crawling.
+	mutex sync.Mutex
+}
+
+// Snapshot of a crawler's progress, used to report health.
+type ClientStatus struct {
+	Name string
+
+	Started bool
+
+	State     string
+	LastError string
+
+	CurrentPage int32
+	TotalMovies int64
+
+	LastPageTime time.Time
+}
+
+func (this *Client) GetCurrentPage() int32 {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.CurrentPage
 }
 
+func (this *Client) SetPageDelay(delay time.Duration) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.PageDelay = delay
+}
+
+func (this *Client) GetStatus() *ClientStatus {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	var status *ClientStatus = new(ClientStatus)
+
+	status.Name = this.Name
+
+	status.Started = this.Started
+
+	status.State = this.State
+	status.LastError = this.LastError
+
+	status.CurrentPage = this.CurrentPage
+	status.TotalMovies = this.TotalMovies
+
+	status.LastPageTime = this.LastPageTime
+
+	return status
+}
+
+// Crawls pages until the service runs out of movies, a page fails or `ctx` is
+// cancelled by Stop. `done` is closed when it returns.
+func (this *Client) crawl(task *TaskManager.Task, ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	var totalMovies int64 = int64(this.GetTotalMovieCount(Logger.WithCorrelationId(ctx, Logger.NewCorrelationId()), this))
+
+	this.mutex.Lock()
+
+	this.TotalMovies = totalMovies
+	this.CurrentPage = this.StartPage
+
+	this.mutex.Unlock()
+
+	var finished bool = false
+	var failure error = nil
+
+	task.SafeLoop(func(loop *TaskManager.TaskSafeLoop) bool {
+		return ctx.Err() == nil
+	}, func(loop *TaskManager.TaskSafeLoop) {
+		var currentPage int32 = this.GetCurrentPage()
+
+		// Every page gets its own id, shared by the upstream requests and torrent parses it leads to.
+		var pageContext context.Context = Logger.WithCorrelationId(ctx, Logger.NewCorrelationId())
+
+		pageContext, span := Tracing.StartSpan(pageContext, "crawler.page",
+			attribute.String(Tracing.ATTRIBUTE_CRAWLER, this.Name),
+			attribute.Int(Tracing.ATTRIBUTE_PAGE, int(currentPage)),
+		)
+
+		movies, err := this.GetSearchResult(pageContext, this)
+
+		if err != nil {
+			// Stop cancels the page in flight, that isn't a failure.
+			if ctx.Err() == nil {
+				failure = err
+
+				Metrics.CrawlerPages.Inc(this.Name, "failed")
+			}
+
+			Tracing.EndSpan(span, err)
+
+			loop.Break()
+			return
+		}
+
+		Metrics.CrawlerPages.Inc(this.Name, "fetched")
+
+		if len(movies) < 1 {
+			Tracing.EndSpan(span, nil)
+
+			finished = true
+
+			loop.Break()
+			return
+		}
+
+		var added int = 0
+		var updated int = 0
+
+		for _, details := range movies {
+			stored, isNew := Catalog.StoreMovieWithContext(pageContext, details)
+
+			if stored == nil {
+				continue
+			}
+
+			var eventType string = Events.EVENT_TYPE_MOVIE_UPDATED
+
+			if isNew {
+				eventType = Events.EVENT_TYPE_MOVIE_ADDED
+
+				added++
+			} else {
+				updated++
+			}
+
+			Events.Publish(eventType, this.Name, Events.EventData{
+				"Slug":  stored.Slug,
+				"Title": stored.Title,
+				"Year":  stored.Year,
+			})
+		}
+
+		Events.Publish(Events.EVENT_TYPE_CRAWLER_PAGE_FETCHED, this.Name, Events.EventData{
+			"Page":        currentPage,
+			"MovieCount":  len(movies),
+			"Added":       added,
+			"Updated":     updated,
+			"TotalMovies": totalMovies,
+		})
+
+		span.SetAttributes(
+			attribute.Int("gserver.page.movie_count", len(movies)),
+			attribute.Int("gserver.page.added", added),
+			attribute.Int("gserver.page.updated", updated),
+		)
+
+		Tracing.EndSpan(span, nil)
+
+		Logger.INFO_CONTEXT(pageContext, "Crawled page.", "crawler", this.Name, "page", currentPage, "added", added, "updated", updated)
+
+		this.mutex.Lock()
+
+		this.LastPageTime = time.Now()
+
+		this.CurrentPage++
+
+		currentPage = this.CurrentPage
+
+		var pageDelay time.Duration = this.PageDelay
+
+		this.mutex.Unlock()
+
+		if totalMovies > 0 && int64(currentPage)*int64(this.Rows) >= totalMovies {
+			finished = true
+
+			loop.Break()
+			return
+		}
+
+		if pageDelay == TaskManager.DISABLED_TASK_DELAY {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(pageDelay):
+		}
+	})
+
+	Logger.INFO("Crawler finished.", "crawler", this.Name)
+
+	this.mutex.Lock()
+
+	var data Events.EventData = Events.EventData{
+		"Page":        this.CurrentPage,
+		"TotalMovies": totalMovies,
+	}
+
+	var eventType string = Events.EVENT_TYPE_CRAWLER_STOPPED
+
+	if failure != nil {
+		data["Error"] = failure.Error()
+
+		this.State = CRAWLER_STATE_FAILED
+		this.LastError = failure.Error()
+
+		eventType = Events.EVENT_TYPE_CRAWLER_FAILED
+	} else if finished {
+		this.State = CRAWLER_STATE_FINISHED
+
+		eventType = Events.EVENT_TYPE_CRAWLER_FINISHED
+	} else {
+		this.State = CRAWLER_STATE_STOPPED
+	}
+
+	// A run replaced by Stop and Start leaves the new run alone.
+	if this.runDone == done {
+		this.Started = false
+
+		this.runCancel()
+
+		this.runCancel = nil
+		this.runDone = nil
+	}
+
+	this.mutex.Unlock()
+
+	Events.Publish(eventType, this.Name, data)
+}
+
+// Stops the running crawl and waits for it to return, so a following Start
+// can't run two crawls at once.
 func (this *Client) Stop() {
+	this.mutex.Lock()
+
 	if !this.Started {
+		this.mutex.Unlock()
 		return
 	}
 
 	this.Started = false
 
 	this.StartPage = 0
+
+	var cancel context.CancelFunc = this.runCancel
+	var done chan struct{} = this.runDone
+
+	this.runCancel = nil
+	this.runDone = nil
+
+	this.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	// The crawl only notices at its next page, a stuck upstream or a paused task
+	// manager shouldn't hang the caller forever.
+	if done != nil {
+		select {
+		case <-done:
+		case <-time.After(CRAWLER_STOP_TIMEOUT):
+			Logger.WARN("Crawler didn't stop in time.", "crawler", this.Name, "timeout", CRAWLER_STOP_TIMEOUT)
+		}
+	}
+
+	this.mutex.Lock()
+
 	this.CurrentPage = 0
+
+	this.mutex.Unlock()
 }
 
 func (this *Client) Start() {
+	this.mutex.Lock()
+
 	if this.Started {
+		this.mutex.Unlock()
 		return
 	}
 
 	this.Started = true
 
-	Logger.INFO("crawler started : ", this.Name)
+	this.State = CRAWLER_STATE_RUNNING
+	this.LastError = ""
+
+	var startPage int32 = this.StartPage
+
+	if this.Tasks == nil {
+		this.mutex.Unlock()
+
+		Logger.INFO("Crawler started.", "crawler", this.Name)
+
+		Events.Publish(Events.EVENT_TYPE_CRAWLER_STARTED, this.Name, Events.EventData{
+			"Page": startPage,
+		})
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(this.Context)
+
+	var done chan struct{} = make(chan struct{})
+
+	this.runCancel = cancel
+	this.runDone = done
+
+	this.mutex.Unlock()
+
+	Logger.INFO("Crawler started.", "crawler", this.Name)
+
+	Events.Publish(Events.EVENT_TYPE_CRAWLER_STARTED, this.Name, Events.EventData{
+		"Page": startPage,
+	})
+
+	this.Tasks.AddTaskWithContext(ctx, func(task *TaskManager.Task) {
+		this.crawl(task, ctx, done)
+	})
+
+	this.Tasks.Start()
 }
 
 func NewClient(ctx context.Context, name string, rows int32, startPage int32) *Client {
@@ -64,12 +394,26 @@ func NewClient(ctx context.Context, name string, rows int32, startPage int32) *C
 
 	client.Started = false
 
-	client.GetSearchResult = func(c *Client) []*Movie.MovieDetails { return []*Movie.MovieDetails{} }
-	client.GetTotalMovieCount = func(c *Client) float64 { return 0 }
+	client.State = CRAWLER_STATE_IDLE
+	client.LastError = ""
+
+	client.LastPageTime = time.Time{}
+
+	client.PageDelay = TaskManager.DISABLED_TASK_DELAY
+
+	client.Tasks = nil
+
+	client.GetSearchResult = func(ctx context.Context, c *Client) ([]*Movie.MovieDetails, error) {
+		return []*Movie.MovieDetails{}, nil
+	}
+	client.GetTotalMovieCount = func(ctx context.Context, c *Client) float64 { return 0 }
 
 	client.Context = ctx
 
 	client.ServiceClient = nil
 
+	client.runCancel = nil
+	client.runDone = nil
+
 	return client
 }
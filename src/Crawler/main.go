@@ -2,11 +2,14 @@ package Crawler
 
 import (
 	"GServer/Config"
+	"GServer/Defaults"
 	"GServer/InternetArchive"
 	"GServer/Logger"
 	"GServer/Movie"
+	"GServer/TaskManager"
 	"GServer/YTS"
 	"context"
+	"errors"
 )
 
 var YTSCrawler *Client = nil
@@ -15,20 +18,30 @@ var InternetArchiveCrawler *Client = nil
 var MainCrawlerContext context.Context = nil
 var MainCrawlerContextCancel context.CancelFunc = nil
 
-func GetYTSSearchResult(client *Client) []*Movie.MovieDetails {
+func GetYTSSearchResult(ctx context.Context, client *Client) ([]*Movie.MovieDetails, error) {
 	ytsClient, ok := client.ServiceClient.(*YTS.Client)
 
 	if !ok {
 		Logger.ERROR("Failed to get YTS client service.")
-		return []*Movie.MovieDetails{}
+		return []*Movie.MovieDetails{}, errors.New("Failed to get YTS client service")
 	}
 
-	Logger.INFO("yts is : ", ytsClient)
+	var params *YTS.MoviesListParameters = YTS.NewMoviesListParameters()
+
+	params.Limit = client.Rows
+	params.Page = client.GetCurrentPage() + 1
+
+	movies, err, _ := ytsClient.GetMovieListWithContext(ctx, params)
 
-	return []*Movie.MovieDetails{}
+	if err != nil {
+		Logger.ERROR_CONTEXT(ctx, "Failed getting YTS movies.", "page", params.Page, "error", err)
+		return []*Movie.MovieDetails{}, err
+	}
+
+	return movies, nil
 }
 
-func GetYTSTotalMovies(client *Client) float64 {
+func GetYTSTotalMovies(ctx context.Context, client *Client) float64 {
 	ytsClient, ok := client.ServiceClient.(*YTS.Client)
 
 	if !ok {
@@ -38,30 +51,40 @@ func GetYTSTotalMovies(client *Client) float64 {
 
 	var params *YTS.MoviesListParameters = YTS.NewMoviesListParameters()
 
-	count, err := ytsClient.GetMovieCount(params)
+	count, err := ytsClient.GetMovieCountWithContext(ctx, params)
 
 	if err != nil {
-		Logger.ERROR("Failed getting movie counts. [Message: " + err.Error() + "]")
+		Logger.ERROR_CONTEXT(ctx, "Failed getting movie counts.", "error", err)
 		return 0
 	}
 
 	return count
 }
 
-func GetInternetArchiveSearchResult(client *Client) []*Movie.MovieDetails {
+func GetInternetArchiveSearchResult(ctx context.Context, client *Client) ([]*Movie.MovieDetails, error) {
 	iaClient, ok := client.ServiceClient.(*InternetArchive.Client)
 
 	if !ok {
 		Logger.ERROR("Failed to get Internet Archive client service.")
-		return []*Movie.MovieDetails{}
+		return []*Movie.MovieDetails{}, errors.New("Failed to get Internet Archive client service")
 	}
 
-	Logger.INFO("ia client is : ", iaClient)
+	var params *InternetArchive.SearchParameters = InternetArchive.NewSearchParameters("")
+
+	params.Rows = client.Rows
+	params.Page = client.GetCurrentPage() + 1
 
-	return []*Movie.MovieDetails{}
+	movies, err, _, _ := iaClient.GetMovieListWithContext(ctx, params, "")
+
+	if err != nil {
+		Logger.ERROR_CONTEXT(ctx, "Failed getting Internet Archive movies.", "page", params.Page, "error", err)
+		return []*Movie.MovieDetails{}, err
+	}
+
+	return movies, nil
 }
 
-func GetInternetArchiveTotalMovies(client *Client) float64 {
+func GetInternetArchiveTotalMovies(ctx context.Context, client *Client) float64 {
 	iaClient, ok := client.ServiceClient.(*InternetArchive.Client)
 
 	if !ok {
@@ -69,18 +92,65 @@ func GetInternetArchiveTotalMovies(client *Client) float64 {
 		return 0
 	}
 
-	Logger.INFO("ia client is : ", iaClient)
+	count, err := iaClient.GetMovieCountWithContext(ctx, InternetArchive.NewSearchParameters(""), "")
+
+	if err != nil {
+		Logger.ERROR_CONTEXT(ctx, "Failed getting movie counts.", "error", err)
+		return 0
+	}
+
+	return count
+}
+
+// Applies reloaded thread limits, delays and service toggles to the running crawlers.
+func applyConfig(previous *Config.Config, current *Config.Config) {
+	var maximumThreads map[string]int = map[string]int{
+		"MOVIE_CRAWLER_YTS":  current.TasksMaxThreads.MOVIE_CRAWLER_YTS,
+		"MOVIE_CRAWLER_IA":   current.TasksMaxThreads.MOVIE_CRAWLER_IA,
+		"YTS_MOVIE_PARSER":   current.TasksMaxThreads.YTS_MOVIE_PARSER,
+		"IA_MOVIE_PARSER":    current.TasksMaxThreads.IA_MOVIE_PARSER,
+		"YTS_TORRENT_PARSER": current.TasksMaxThreads.YTS_TORRENT_PARSER,
+		"IA_TORRENT_PARSER":  current.TasksMaxThreads.IA_TORRENT_PARSER,
+	}
+
+	for name, threads := range maximumThreads {
+		TaskManager.SetMaximumThreads(name, threads)
+	}
 
-	return 0
+	// Stopped crawlers stay stopped until the next start.
+	if MainCrawlerContext == nil || MainCrawlerContext.Err() != nil {
+		return
+	}
+
+	YTSCrawler.SetPageDelay(current.TasksExecutionDelay.MOVIE_CRAWLER_YTS)
+	InternetArchiveCrawler.SetPageDelay(current.TasksExecutionDelay.MOVIE_CRAWLER_IA)
+
+	if current.CanUseYTSService != previous.CanUseYTSService {
+		if current.CanUseYTSService {
+			YTSCrawler.Start()
+		} else {
+			YTSCrawler.Stop()
+		}
+	}
+
+	if current.CanUseInternetArchiveService != previous.CanUseInternetArchiveService {
+		if current.CanUseInternetArchiveService {
+			InternetArchiveCrawler.Start()
+		} else {
+			InternetArchiveCrawler.Stop()
+		}
+	}
 }
 
 func Initialize() {
 	Logger.INFO("Initializing crawler...")
 
+	var config Config.Config = Config.Get()
+
 	MainCrawlerContext, MainCrawlerContextCancel = context.WithCancel(context.Background())
 
-	YTSCrawler = NewClient(MainCrawlerContext, "YTS Crawler", int32(Config.Main.Crawler.YTSMovieCountPerSearch), 0)
-	InternetArchiveCrawler = NewClient(MainCrawlerContext, "Internet Archive Crawler", int32(Config.Main.Crawler.InternetArchiveMovieCountPerSearch), 0)
+	YTSCrawler = NewClient(MainCrawlerContext, "YTS Crawler", int32(config.Crawler.YTSMovieCountPerSearch), 0)
+	InternetArchiveCrawler = NewClient(MainCrawlerContext, "Internet Archive Crawler", int32(config.Crawler.InternetArchiveMovieCountPerSearch), 0)
 
 	YTSCrawler.GetSearchResult = GetYTSSearchResult
 	InternetArchiveCrawler.GetSearchResult = GetInternetArchiveSearchResult
@@ -88,16 +158,25 @@ func Initialize() {
 	YTSCrawler.GetTotalMovieCount = GetYTSTotalMovies
 	InternetArchiveCrawler.GetTotalMovieCount = GetInternetArchiveTotalMovies
 
-	YTSCrawler.ServiceClient = YTS.NewClient(YTSCrawler.Context, 0)
+	YTSCrawler.ServiceClient = YTS.NewClient(YTSCrawler.Context, Defaults.CRAWLER_SERVICE_REQUEST_TIMEOUT)
+	InternetArchiveCrawler.ServiceClient = InternetArchive.NewClient(InternetArchiveCrawler.Context, Defaults.CRAWLER_SERVICE_REQUEST_TIMEOUT)
+
+	YTSCrawler.PageDelay = config.TasksExecutionDelay.MOVIE_CRAWLER_YTS
+	InternetArchiveCrawler.PageDelay = config.TasksExecutionDelay.MOVIE_CRAWLER_IA
 
-	if Config.Main.CanUseYTSService {
+	YTSCrawler.Tasks = TaskManager.CreateTaskManagerWithContext(MainCrawlerContext, "MOVIE_CRAWLER_YTS", config.TasksMaxThreads.MOVIE_CRAWLER_YTS)
+	InternetArchiveCrawler.Tasks = TaskManager.CreateTaskManagerWithContext(MainCrawlerContext, "MOVIE_CRAWLER_IA", config.TasksMaxThreads.MOVIE_CRAWLER_IA)
+
+	if config.CanUseYTSService {
 		YTSCrawler.Start()
 	}
 
-	if Config.Main.CanUseInternetArchiveService {
+	if config.CanUseInternetArchiveService {
 		InternetArchiveCrawler.Start()
 	}
 
+	Config.AddReloadListener(applyConfig)
+
 	Logger.INFO("Crawler initialized.")
 }
 
@@ -107,5 +186,10 @@ func Uninitialize() {
 	YTSCrawler.Stop()
 	InternetArchiveCrawler.Stop()
 
+	MainCrawlerContextCancel()
+
+	TaskManager.DeleteTaskManager(YTSCrawler.Tasks.Name)
+	TaskManager.DeleteTaskManager(InternetArchiveCrawler.Tasks.Name)
+
 	Logger.INFO("Crawler uninitialized.")
 }
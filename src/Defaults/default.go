@@ -2,11 +2,20 @@ package Defaults
 
 import (
 	"GServer/TaskManager"
+	"time"
 )
 
 const (
 	DEFAULT_HTTP_SERVER_HOST_ADDRESS = "localhost:3050"
 
+	HTTP_SERVER_READ_TIMEOUT        = time.Second * 30
+	HTTP_SERVER_READ_HEADER_TIMEOUT = time.Second * 10
+	HTTP_SERVER_WRITE_TIMEOUT       = time.Minute * 1
+	HTTP_SERVER_IDLE_TIMEOUT        = time.Minute * 2
+	HTTP_SERVER_SHUTDOWN_TIMEOUT    = time.Second * 15
+	HTTP_SERVER_MAX_HEADER_BYTES    = 1 << 20
+	HTTP_SERVER_TLS_RELOAD_INTERVAL = time.Second * 30
+
 	YTS_API_BASE_URL                  = "https://yts.mx/api/v2"
 	YTS_API_LIST_MOVIES_ENDPOINT      = "/list_movies.json"
 	YTS_API_MOVIE_DETAILS_ENDPOINT    = "/movie_details.json"
@@ -19,6 +28,41 @@ const (
 	CRAWLER_YTS_MOVIE_COUNT_PER_SEARCH              = 30
 	CRAWLER_INTERNET_ARCHIVE_MOVIE_COUNT_PER_SAERCH = 30
 
+	CRAWLER_SERVICE_REQUEST_TIMEOUT = time.Minute * 2
+
+	WEBHOOKS_MAX_ATTEMPTS          = 5
+	WEBHOOKS_RETRY_BACKOFF         = time.Second * 2
+	WEBHOOKS_MAXIMUM_RETRY_BACKOFF = time.Minute * 5
+	WEBHOOKS_REQUEST_TIMEOUT       = time.Second * 10
+	WEBHOOKS_DELIVERY_LOG_SIZE     = 1000
+
+	API_KEYS_FILE_NAME              = "api_keys.json"
+	API_KEYS_BOOTSTRAP_FILE_NAME    = "bootstrap_admin_api_key.txt"
+	API_KEYS_DEFAULT_QUOTA_REQUESTS = 3600
+	API_KEYS_DEFAULT_QUOTA_PERIOD   = time.Hour * 1
+
+	LOGGING_LEVEL  = "info"
+	LOGGING_FORMAT = "text"
+
+	LOGGING_FILE_MAX_SIZE          = 100 << 20
+	LOGGING_FILE_ROTATION_INTERVAL = time.Hour * 24
+	LOGGING_FILE_MAX_BACKUPS       = 10
+	LOGGING_FILE_MAX_BACKUP_AGE    = time.Hour * 24 * 30
+
+	TRACING_EXPORTER         = "otlp"
+	TRACING_SERVICE_NAME     = "gserver"
+	TRACING_OTLP_ENDPOINT    = "http://localhost:4318/v1/traces"
+	TRACING_FILE_NAME        = "traces.jsonl"
+	TRACING_SAMPLE_RATIO     = 1.0
+	TRACING_SHUTDOWN_TIMEOUT = time.Second * 5
+
+	TRACING_FILE_MAX_SIZE          = 100 << 20
+	TRACING_FILE_ROTATION_INTERVAL = time.Hour * 24
+	TRACING_FILE_MAX_BACKUPS       = 5
+	TRACING_FILE_MAX_BACKUP_AGE    = time.Hour * 24 * 7
+
+	CONFIG_RELOAD_WATCH_INTERVAL = time.Second * 5
+
 	TASKS_MAX_THREADS_HTTP_SERVER = TaskManager.UNLIMITED_THREAD_COUNT
 
 	TASKS_MAX_THREADS_CRAWLER_MAIN = TaskManager.UNLIMITED_THREAD_COUNT
@@ -32,6 +76,8 @@ const (
 	TASKS_MAX_THREADS_YTS_TORRENT_PARSER = 10
 	TASKS_MAX_THREADS_IA_TORRENT_PARSER  = 10
 
+	TASKS_MAX_THREADS_WEBHOOKS = 10
+
 	TASKS_EXECUTION_DELAY_HTTP_SERVER = TaskManager.DISABLED_TASK_DELAY
 
 	TASKS_EXECUTION_DELAY_CRAWLER_MAIN = TaskManager.DISABLED_TASK_DELAY
@@ -0,0 +1,338 @@
+package Events
+
+import (
+	"GServer/Logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EVENT_TYPE_CRAWLER_STARTED      = "crawler.started"
+	EVENT_TYPE_CRAWLER_STOPPED      = "crawler.stopped"
+	EVENT_TYPE_CRAWLER_FINISHED     = "crawler.finished"
+	EVENT_TYPE_CRAWLER_FAILED       = "crawler.failed"
+	EVENT_TYPE_CRAWLER_PAGE_FETCHED = "crawler.page_fetched"
+
+	EVENT_TYPE_MOVIE_ADDED   = "movie.added"
+	EVENT_TYPE_MOVIE_UPDATED = "movie.updated"
+
+	EVENT_TYPE_TORRENT_ADDED        = "torrent.added"
+	EVENT_TYPE_TORRENT_PARSE_FAILED = "torrent.parse_failed"
+
+	// Recent events kept so reconnecting clients can catch up on what they missed.
+	EVENT_HISTORY_SIZE = 256
+
+	EVENT_SUBSCRIPTION_BUFFER_SIZE = 128
+)
+
+type EventData = map[string]any
+
+type Event struct {
+	Id   uint64
+	Type string
+	Time time.Time
+
+	Source string
+
+	Data EventData
+}
+
+type Subscription struct {
+	Id uint64
+
+	// Event types delivered to the subscription, every type when empty.
+	Types map[string]bool
+
+	Channel chan *Event
+
+	// Events dropped because the subscriber didn't keep up.
+	Dropped uint64
+
+	closed bool
+
+	// Queued subscriptions never drop events, Publish appends them to `queue` and
+	// a goroutine feeds them to the channel.
+	queued bool
+	queue  []*Event
+
+	queueSignal chan struct{}
+	queueDone   chan struct{}
+}
+
+var EventTypes []string = []string{
+	EVENT_TYPE_CRAWLER_STARTED,
+	EVENT_TYPE_CRAWLER_STOPPED,
+	EVENT_TYPE_CRAWLER_FINISHED,
+	EVENT_TYPE_CRAWLER_FAILED,
+	EVENT_TYPE_CRAWLER_PAGE_FETCHED,
+	EVENT_TYPE_MOVIE_ADDED,
+	EVENT_TYPE_MOVIE_UPDATED,
+	EVENT_TYPE_TORRENT_ADDED,
+	EVENT_TYPE_TORRENT_PARSE_FAILED,
+}
+
+var history []*Event = nil
+var subscriptions map[uint64]*Subscription = nil
+
+var lastEventId uint64 = 0
+var lastSubscriptionId uint64 = 0
+
+var mutex sync.Mutex
+
+func (this *Subscription) Accepts(eventType string) bool {
+	if len(this.Types) < 1 {
+		return true
+	}
+
+	return this.Types[eventType]
+}
+
+// Parses a comma separated list of event types, "crawler.*" selects every type
+// starting with "crawler.".
+func ParseEventTypes(value string) map[string]bool {
+	var types map[string]bool = map[string]bool{}
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if len(name) < 1 {
+			continue
+		}
+
+		if !strings.HasSuffix(name, "*") {
+			types[name] = true
+			continue
+		}
+
+		for _, eventType := range EventTypes {
+			if strings.HasPrefix(eventType, strings.TrimSuffix(name, "*")) {
+				types[eventType] = true
+			}
+		}
+	}
+
+	return types
+}
+
+// Hands `event` to `subscription`, dropping it when a buffered subscription is full.
+// Must be called with the mutex held.
+func (this *Subscription) push(event *Event) {
+	if this.queued {
+		this.queue = append(this.queue, event)
+
+		select {
+		case this.queueSignal <- struct{}{}:
+		default:
+		}
+
+		return
+	}
+
+	select {
+	case this.Channel <- event:
+	default:
+		this.Dropped++
+	}
+}
+
+// Feeds the queue of `subscription` to its channel until it's unsubscribed.
+func (this *Subscription) feed() {
+	defer close(this.Channel)
+
+	for {
+		select {
+		case <-this.queueDone:
+			return
+		case <-this.queueSignal:
+		}
+
+		mutex.Lock()
+
+		var events []*Event = this.queue
+
+		this.queue = nil
+
+		mutex.Unlock()
+
+		for _, event := range events {
+			select {
+			case this.Channel <- event:
+			case <-this.queueDone:
+				return
+			}
+		}
+	}
+}
+
+// Publishes an event to every subscription accepting its type. Never blocks, events
+// are dropped for buffered subscriptions whose buffer is full.
+func Publish(eventType string, source string, data EventData) *Event {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if subscriptions == nil {
+		return nil
+	}
+
+	lastEventId++
+
+	var event *Event = new(Event)
+
+	event.Id = lastEventId
+	event.Type = eventType
+	event.Time = time.Now()
+
+	event.Source = source
+
+	event.Data = data
+
+	if event.Data == nil {
+		event.Data = EventData{}
+	}
+
+	history = append(history, event)
+
+	if len(history) > EVENT_HISTORY_SIZE {
+		history = history[len(history)-EVENT_HISTORY_SIZE:]
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Accepts(eventType) {
+			continue
+		}
+
+		subscription.push(event)
+	}
+
+	return event
+}
+
+// Subscribes to the events of `types`, every type when empty. Events published
+// after `afterId` and still in history are queued first.
+func Subscribe(types map[string]bool, afterId uint64) *Subscription {
+	return SubscribeWithBufferSize(types, afterId, EVENT_SUBSCRIPTION_BUFFER_SIZE)
+}
+
+func SubscribeWithBufferSize(types map[string]bool, afterId uint64, bufferSize int) *Subscription {
+	return subscribe(types, afterId, bufferSize, false)
+}
+
+// Subscribes to the events of `types` without ever dropping one, events wait in
+// memory until the subscriber takes them. For subscribers that must see every
+// event and hand them off quickly.
+func SubscribeQueued(types map[string]bool, afterId uint64) *Subscription {
+	return subscribe(types, afterId, 0, true)
+}
+
+func subscribe(types map[string]bool, afterId uint64, bufferSize int, queued bool) *Subscription {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if subscriptions == nil {
+		return nil
+	}
+
+	lastSubscriptionId++
+
+	var subscription *Subscription = new(Subscription)
+
+	subscription.Id = lastSubscriptionId
+	subscription.Types = types
+	subscription.Channel = make(chan *Event, bufferSize)
+	subscription.Dropped = 0
+
+	subscription.closed = false
+
+	subscription.queued = queued
+
+	if queued {
+		subscription.queueSignal = make(chan struct{}, 1)
+		subscription.queueDone = make(chan struct{})
+
+		go subscription.feed()
+	}
+
+	if afterId > 0 {
+		for _, event := range history {
+			if event.Id <= afterId || !subscription.Accepts(event.Type) {
+				continue
+			}
+
+			subscription.push(event)
+		}
+	}
+
+	subscriptions[subscription.Id] = subscription
+
+	return subscription
+}
+
+func Unsubscribe(subscription *Subscription) {
+	if subscription == nil {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if subscription.closed {
+		return
+	}
+
+	delete(subscriptions, subscription.Id)
+
+	subscription.close()
+}
+
+// Must be called with the mutex held.
+func (this *Subscription) close() {
+	this.closed = true
+
+	// The feeding goroutine closes the channel of queued subscriptions.
+	if this.queued {
+		this.queue = nil
+
+		close(this.queueDone)
+		return
+	}
+
+	close(this.Channel)
+}
+
+func GetSubscriptionCount() int {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return len(subscriptions)
+}
+
+func Initialize() {
+	Logger.INFO("Initializing events...")
+
+	mutex.Lock()
+
+	history = []*Event{}
+	subscriptions = map[uint64]*Subscription{}
+
+	mutex.Unlock()
+
+	Logger.INFO("Events initialized.")
+}
+
+func Uninitialize() {
+	Logger.INFO("Uninitializing events...")
+
+	mutex.Lock()
+
+	for _, subscription := range subscriptions {
+		subscription.close()
+	}
+
+	subscriptions = nil
+	history = nil
+
+	mutex.Unlock()
+
+	Logger.INFO("Events uninitialized.")
+}
@@ -0,0 +1,200 @@
+package HttpServer
+
+import (
+	"GServer/Auth"
+	"GServer/Webhooks"
+	"errors"
+	HTTP "net/http"
+	"sort"
+	"time"
+)
+
+const (
+	DEFAULT_WEBHOOK_DELIVERIES_LIMIT = 50
+	MAXIMUM_WEBHOOK_DELIVERIES_LIMIT = 500
+)
+
+func h_AdminWebhooks(response Response, request Request) {
+	var targets []map[string]any = []map[string]any{}
+
+	for _, target := range Webhooks.Targets {
+		var events []string = []string{}
+
+		for eventType := range target.Events {
+			events = append(events, eventType)
+		}
+
+		sort.Strings(events)
+
+		// Secrets never leave the server.
+		targets = append(targets, map[string]any{
+			"URL":      target.URL,
+			"Events":   events,
+			"IsSigned": len(target.Secret) > 0,
+		})
+	}
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"target_count": len(targets),
+			"targets":      targets,
+		},
+	})
+}
+
+func h_AdminWebhookDeliveries(response Response, request Request) {
+	limit, err := getQueryInt(request, "limit", DEFAULT_WEBHOOK_DELIVERIES_LIMIT)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	var query *Webhooks.DeliveryQuery = Webhooks.NewDeliveryQuery()
+
+	query.Status = request.URL.Query().Get("status")
+	query.EventType = request.URL.Query().Get("event")
+	query.URL = request.URL.Query().Get("url")
+
+	query.Limit = min(max(limit, 1), MAXIMUM_WEBHOOK_DELIVERIES_LIMIT)
+
+	var deliveries []*Webhooks.Delivery = Webhooks.GetDeliveries(query)
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"delivery_count": len(deliveries),
+			"limit":          query.Limit,
+			"deliveries":     deliveries,
+		},
+	})
+}
+
+func h_AdminWebhookDelivery(response Response, request Request) {
+	var id string = request.URL.Query().Get("id")
+
+	if len(id) < 1 {
+		writeJsonError(response, HTTP.StatusBadRequest, "Missing `id` parameter")
+		return
+	}
+
+	var delivery *Webhooks.Delivery = Webhooks.GetDelivery(id)
+
+	if delivery == nil {
+		writeJsonError(response, HTTP.StatusNotFound, "Delivery not found")
+		return
+	}
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"delivery": delivery,
+		},
+	})
+}
+
+// Hashes stay on the server, like webhook secrets.
+func getApiKeyData(key *Auth.ApiKey) map[string]any {
+	requests, period := key.GetQuota()
+
+	var data map[string]any = map[string]any{
+		"Id":     key.Id,
+		"Name":   key.Name,
+		"Scopes": key.Scopes,
+		"Source": key.Source,
+
+		"QuotaRequests":      requests,
+		"QuotaPeriodSeconds": int64(period.Seconds()),
+
+		"CreatedAt": key.CreatedAt,
+		"Revoked":   key.Revoked,
+	}
+
+	if key.Revoked {
+		data["RevokedAt"] = key.RevokedAt
+	}
+
+	return data
+}
+
+func h_AdminApiKeys(response Response, request Request) {
+	var keys []map[string]any = []map[string]any{}
+
+	for _, key := range Auth.GetApiKeys() {
+		keys = append(keys, getApiKeyData(key))
+	}
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"enabled":   Auth.Enabled,
+			"key_count": len(keys),
+			"keys":      keys,
+		},
+	})
+}
+
+func h_AdminIssueApiKey(response Response, request Request) {
+	scopes, err := Auth.ParseScopes(request.URL.Query().Get("scopes"))
+
+	if err != nil {
+		writeApiError(response, HTTP.StatusBadRequest, newApiError(API_ERROR_CODE_INVALID_PARAMETER, err.Error(), "scopes"))
+		return
+	}
+
+	if len(scopes) < 1 {
+		writeApiError(response, HTTP.StatusBadRequest, newApiError(API_ERROR_CODE_MISSING_PARAMETER, "Missing `scopes` parameter", "scopes"))
+		return
+	}
+
+	quotaRequests, err := getQueryInt(request, "quota_requests", 0)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	quotaPeriod, err := getQueryInt(request, "quota_period", 0)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	issued, err := Auth.IssueApiKey(request.URL.Query().Get("name"), scopes, quotaRequests, time.Duration(quotaPeriod)*time.Second)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusInternalServerError, "Couldn't issue API key: "+err.Error())
+		return
+	}
+
+	writeJson(response, HTTP.StatusCreated, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"key":     issued.Key,
+			"api_key": getApiKeyData(issued.ApiKey),
+		},
+	})
+}
+
+func h_AdminRevokeApiKey(response Response, request Request) {
+	key, err := Auth.RevokeApiKey(request.URL.Query().Get("id"))
+
+	if errors.Is(err, Auth.ErrApiKeyNotFound) {
+		writeJsonError(response, HTTP.StatusNotFound, err.Error())
+		return
+	}
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"api_key": getApiKeyData(key),
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package HttpServer
+
+import (
+	"GServer/Auth"
+	HTTP "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	API_KEY_HEADER          = "X-API-Key"
+	API_KEY_QUERY_PARAMETER = "apikey"
+)
+
+// Browsers can't set headers on WebSocket and EventSource requests, and Torznab
+// clients and feed readers only know the query parameter.
+func getRequestApiKey(request Request) string {
+	if authorization := request.Header.Get("Authorization"); len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+
+	if key := request.Header.Get(API_KEY_HEADER); len(key) > 0 {
+		return key
+	}
+
+	return request.URL.Query().Get(API_KEY_QUERY_PARAMETER)
+}
+
+func setQuotaHeaders(response Response, quota *Auth.QuotaStatus) {
+	if quota.Limit < 0 {
+		return
+	}
+
+	response.Header().Set("X-RateLimit-Limit", strconv.Itoa(quota.Limit))
+	response.Header().Set("X-RateLimit-Remaining", strconv.Itoa(quota.Remaining))
+	response.Header().Set("X-RateLimit-Reset", strconv.FormatInt(quota.Reset.Unix(), 10))
+}
+
+// Checks the API key of the request against the endpoint scope and counts it
+// against the key's quota. Public endpoints and a disabled Auth let everything through.
+func (this *ApiEndpoint) Authorize(response Response, request Request) (int, *ApiError) {
+	if !Auth.Enabled || len(this.Scope) < 1 {
+		return HTTP.StatusOK, nil
+	}
+
+	var value string = getRequestApiKey(request)
+
+	if len(value) < 1 {
+		response.Header().Set("WWW-Authenticate", `Bearer realm="GServer"`)
+
+		return HTTP.StatusUnauthorized, newApiError(API_ERROR_CODE_UNAUTHORIZED, "Missing API key", API_KEY_QUERY_PARAMETER)
+	}
+
+	key, err := Auth.Authenticate(value)
+
+	if err != nil {
+		response.Header().Set("WWW-Authenticate", `Bearer realm="GServer", error="invalid_token"`)
+
+		return HTTP.StatusUnauthorized, newApiError(API_ERROR_CODE_UNAUTHORIZED, err.Error(), API_KEY_QUERY_PARAMETER)
+	}
+
+	if !key.HasScope(this.Scope) {
+		return HTTP.StatusForbidden, newApiError(API_ERROR_CODE_FORBIDDEN, "API key lacks the `"+this.Scope+"` scope", "")
+	}
+
+	var quota *Auth.QuotaStatus = Auth.ConsumeQuota(key)
+
+	setQuotaHeaders(response, quota)
+
+	if !quota.Allowed {
+		var retryAfter int64 = int64(time.Until(quota.Reset).Seconds()) + 1
+
+		response.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+
+		return HTTP.StatusTooManyRequests, newApiError(API_ERROR_CODE_QUOTA_EXCEEDED, "API key quota of "+strconv.Itoa(quota.Limit)+" requests exceeded", "")
+	}
+
+	return HTTP.StatusOK, nil
+}
+
+// Torznab clients only understand Torznab error documents, sent with status 200.
+func writeTorznabApiError(response Response, status int, apiError *ApiError) {
+	var code int = TORZNAB_ERROR_UNKNOWN
+
+	switch status {
+	case HTTP.StatusUnauthorized:
+		code = TORZNAB_ERROR_INCORRECT_CREDENTIALS
+	case HTTP.StatusForbidden:
+		code = TORZNAB_ERROR_INSUFFICIENT_RIGHTS
+	case HTTP.StatusTooManyRequests:
+		code = TORZNAB_ERROR_REQUEST_LIMIT_REACHED
+	case HTTP.StatusBadRequest:
+		code = TORZNAB_ERROR_INCORRECT_PARAMETER
+	}
+
+	writeTorznabError(response, code, apiError.Message)
+}
@@ -0,0 +1,33 @@
+package HttpServer
+
+import (
+	"GServer/Logger"
+)
+
+const (
+	CORRELATION_ID_HEADER = "X-Correlation-ID"
+
+	// Accepted as well, proxies and load balancers usually set this one.
+	REQUEST_ID_HEADER = "X-Request-ID"
+)
+
+// Reuses the id sent by the client or a proxy in front of us, so a request can
+// be followed across services, and makes up one otherwise.
+func getRequestCorrelationId(request Request) string {
+	for _, header := range []string{CORRELATION_ID_HEADER, REQUEST_ID_HEADER} {
+		if id := request.Header.Get(header); Logger.IsValidCorrelationId(id) {
+			return id
+		}
+	}
+
+	return Logger.NewCorrelationId()
+}
+
+// Attaches the correlation id to the request context and echoes it in the response headers.
+func withCorrelationId(response Response, request Request) Request {
+	var id string = getRequestCorrelationId(request)
+
+	response.Header().Set(CORRELATION_ID_HEADER, id)
+
+	return request.WithContext(Logger.WithCorrelationId(request.Context(), id))
+}
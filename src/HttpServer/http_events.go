@@ -0,0 +1,109 @@
+package HttpServer
+
+import (
+	"GServer/Events"
+	"encoding/json"
+	"fmt"
+	HTTP "net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	EVENT_STREAM_HEARTBEAT_INTERVAL = time.Second * 15
+
+	// Milliseconds browsers wait before reconnecting a dropped stream.
+	EVENT_STREAM_RETRY_INTERVAL = 3000
+)
+
+func writeServerSentEvent(response Response, event *Events.Event) error {
+	data, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(response, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+
+	return err
+}
+
+// Streams catalog and crawler events as Server-Sent Events. `types` limits the
+// stream to a comma separated list of event types, reconnecting clients resume
+// after the `Last-Event-ID` header or `last_event_id` parameter.
+func h_Events(response Response, request Request) {
+	flusher, ok := response.(HTTP.Flusher)
+
+	if !ok {
+		writeJsonError(response, HTTP.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	var lastEventIdValue string = request.Header.Get("Last-Event-ID")
+
+	if len(lastEventIdValue) < 1 {
+		lastEventIdValue = request.URL.Query().Get("last_event_id")
+	}
+
+	var lastEventId uint64 = 0
+
+	if len(lastEventIdValue) > 0 {
+		id, err := strconv.ParseUint(lastEventIdValue, 10, 64)
+
+		if err != nil {
+			writeJsonError(response, HTTP.StatusBadRequest, "Invalid `Last-Event-ID`, it must be an event id")
+			return
+		}
+
+		lastEventId = id
+	}
+
+	var subscription *Events.Subscription = Events.Subscribe(Events.ParseEventTypes(request.URL.Query().Get("types")), lastEventId)
+
+	if subscription == nil {
+		writeJsonError(response, HTTP.StatusServiceUnavailable, "Events are not available")
+		return
+	}
+
+	defer Events.Unsubscribe(subscription)
+
+	response.Header().Set("Content-Type", "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+	response.Header().Set("X-Accel-Buffering", "no")
+
+	// Streams outlive the server's write timeout.
+	HTTP.NewResponseController(response).SetWriteDeadline(time.Time{})
+
+	response.WriteHeader(HTTP.StatusOK)
+
+	fmt.Fprintf(response, "retry: %d\n\n", EVENT_STREAM_RETRY_INTERVAL)
+
+	flusher.Flush()
+
+	var heartbeat *time.Ticker = time.NewTicker(EVENT_STREAM_HEARTBEAT_INTERVAL)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-Context.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(response, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-subscription.Channel:
+			if !ok {
+				return
+			}
+
+			if err := writeServerSentEvent(response, event); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,373 @@
+package HttpServer
+
+import (
+	"GServer/Catalog"
+	"GServer/Movie"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	HTTP "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FEED_FORMAT_RSS  = "rss"
+	FEED_FORMAT_ATOM = "atom"
+
+	DEFAULT_FEED_LIMIT = 50
+	MAXIMUM_FEED_LIMIT = 100
+
+	FEED_TITLE = "GServer - Latest movies"
+
+	ATOM_NAMESPACE = "http://www.w3.org/2005/Atom"
+)
+
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type RSSGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type RSSItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description,omitempty"`
+	Categories  []string      `xml:"category"`
+	Guid        RSSGuid       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *RSSEnclosure `xml:"enclosure,omitempty"`
+}
+
+type RSSFeed struct {
+	XMLName xml.Name `xml:"rss"`
+
+	Version string `xml:"version,attr"`
+
+	Channel struct {
+		Title         string     `xml:"title"`
+		Link          string     `xml:"link"`
+		Description   string     `xml:"description"`
+		LastBuildDate string     `xml:"lastBuildDate"`
+		Items         []*RSSItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Title  string `xml:"title,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type AtomEntry struct {
+	Title      string          `xml:"title"`
+	Id         string          `xml:"id"`
+	Updated    string          `xml:"updated"`
+	Published  string          `xml:"published"`
+	Summary    string          `xml:"summary,omitempty"`
+	Categories []*AtomCategory `xml:"category"`
+	Links      []*AtomLink     `xml:"link"`
+}
+
+type AtomFeed struct {
+	XMLName xml.Name `xml:"feed"`
+
+	Namespace string `xml:"xmlns,attr"`
+
+	Title   string       `xml:"title"`
+	Id      string       `xml:"id"`
+	Updated string       `xml:"updated"`
+	Links   []*AtomLink  `xml:"link"`
+	Entries []*AtomEntry `xml:"entry"`
+}
+
+func getFeedFormat(request Request) string {
+	var format string = strings.ToLower(request.URL.Query().Get("format"))
+
+	if len(format) > 0 {
+		return format
+	}
+
+	if strings.Contains(request.Header.Get("Accept"), "application/atom+xml") {
+		return FEED_FORMAT_ATOM
+	}
+
+	return FEED_FORMAT_RSS
+}
+
+func getRequestBaseURL(request Request) string {
+	var scheme string = "http"
+
+	if request.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + request.Host
+}
+
+func getMovieURL(request Request, details *Movie.MovieDetails) string {
+	return getRequestBaseURL(request) + "/api/movie?slug=" + url.QueryEscape(details.Slug)
+}
+
+// Picks the torrent a feed item points to: the first of the requested quality,
+// otherwise the best seeded one.
+func getFeedTorrent(details *Movie.MovieDetails, quality string) *Movie.MovieTorrentInfo {
+	var best *Movie.MovieTorrentInfo = nil
+
+	for _, torrent := range details.Torrents {
+		if len(torrent.URL) < 1 && len(torrent.Magent) < 1 {
+			continue
+		}
+
+		if len(quality) > 0 {
+			if strings.EqualFold(torrent.Quality, quality) {
+				return torrent
+			}
+
+			continue
+		}
+
+		if best == nil || torrent.Seeds > best.Seeds {
+			best = torrent
+		}
+	}
+
+	return best
+}
+
+func getTorrentLink(torrent *Movie.MovieTorrentInfo, preferMagnet bool) string {
+	if len(torrent.Magent) > 0 && (preferMagnet || len(torrent.URL) < 1) {
+		return torrent.Magent
+	}
+
+	return torrent.URL
+}
+
+func getFeedItemTitle(details *Movie.MovieDetails) string {
+	if len(details.TitleLong) > 0 {
+		return details.TitleLong
+	}
+
+	return details.Title
+}
+
+func getFeedItemSummary(details *Movie.MovieDetails) string {
+	if len(details.Summery) > 0 {
+		return details.Summery
+	}
+
+	return details.DescriptionFull
+}
+
+func newRSSFeed(request Request, movies []*Catalog.LatestMovie, modified time.Time, quality string, preferMagnet bool) *RSSFeed {
+	var feed *RSSFeed = new(RSSFeed)
+
+	feed.Version = "2.0"
+
+	feed.Channel.Title = FEED_TITLE
+	feed.Channel.Link = getRequestBaseURL(request) + "/"
+	feed.Channel.Description = "Movies most recently added to the GServer catalog"
+	feed.Channel.LastBuildDate = modified.UTC().Format(time.RFC1123Z)
+
+	feed.Channel.Items = []*RSSItem{}
+
+	for _, latest := range movies {
+		var details *Movie.MovieDetails = latest.Movie
+
+		var item *RSSItem = new(RSSItem)
+
+		item.Title = getFeedItemTitle(details)
+		item.Link = getMovieURL(request, details)
+		item.Description = getFeedItemSummary(details)
+		item.Categories = details.Genres
+
+		item.Guid.IsPermaLink = false
+		item.Guid.Value = details.Slug
+
+		item.PubDate = latest.AddedAt.UTC().Format(time.RFC1123Z)
+
+		if torrent := getFeedTorrent(details, quality); torrent != nil {
+			item.Enclosure = new(RSSEnclosure)
+
+			item.Enclosure.URL = getTorrentLink(torrent, preferMagnet)
+			item.Enclosure.Length = int64(torrent.Size)
+			item.Enclosure.Type = "application/x-bittorrent"
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	return feed
+}
+
+func newAtomFeed(request Request, movies []*Catalog.LatestMovie, modified time.Time, quality string, preferMagnet bool) *AtomFeed {
+	var feed *AtomFeed = new(AtomFeed)
+
+	feed.Namespace = ATOM_NAMESPACE
+
+	feed.Title = FEED_TITLE
+	feed.Id = getRequestBaseURL(request) + "/feeds/latest"
+	feed.Updated = modified.UTC().Format(time.RFC3339)
+
+	feed.Links = []*AtomLink{
+		{Href: getRequestBaseURL(request) + request.URL.RequestURI(), Rel: "self", Type: "application/atom+xml"},
+	}
+
+	feed.Entries = []*AtomEntry{}
+
+	for _, latest := range movies {
+		var details *Movie.MovieDetails = latest.Movie
+
+		var entry *AtomEntry = new(AtomEntry)
+
+		entry.Title = getFeedItemTitle(details)
+		entry.Id = "urn:gserver:movie:" + details.Slug
+		entry.Published = latest.AddedAt.UTC().Format(time.RFC3339)
+		entry.Updated = entry.Published
+		entry.Summary = getFeedItemSummary(details)
+
+		entry.Categories = []*AtomCategory{}
+
+		for _, genre := range details.Genres {
+			entry.Categories = append(entry.Categories, &AtomCategory{Term: genre})
+		}
+
+		entry.Links = []*AtomLink{
+			{Href: getMovieURL(request, details), Rel: "alternate"},
+		}
+
+		// Atom entries can carry every torrent, each as its own enclosure.
+		for _, torrent := range details.Torrents {
+			if len(quality) > 0 && !strings.EqualFold(torrent.Quality, quality) {
+				continue
+			}
+
+			var link string = getTorrentLink(torrent, preferMagnet)
+
+			if len(link) < 1 {
+				continue
+			}
+
+			entry.Links = append(entry.Links, &AtomLink{
+				Href:   link,
+				Rel:    "enclosure",
+				Type:   "application/x-bittorrent",
+				Title:  strings.TrimSpace(torrent.Quality + " " + torrent.Type),
+				Length: int64(torrent.Size),
+			})
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+func getFeedETag(modified time.Time, request Request, format string) string {
+	var hash [20]byte = sha1.Sum([]byte(strconv.FormatInt(modified.UnixNano(), 10) + "|" + format + "|" + request.URL.RawQuery))
+
+	return `W/"` + hex.EncodeToString(hash[:8]) + `"`
+}
+
+func isFeedNotModified(request Request, etag string, modified time.Time) bool {
+	if match := request.Header.Get("If-None-Match"); len(match) > 0 {
+		for _, value := range strings.Split(match, ",") {
+			value = strings.TrimSpace(value)
+
+			if value == "*" || value == etag || "W/"+value == etag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if since := request.Header.Get("If-Modified-Since"); len(since) > 0 {
+		sinceTime, err := HTTP.ParseTime(since)
+
+		return err == nil && !modified.Truncate(time.Second).After(sinceTime)
+	}
+
+	return false
+}
+
+// Serves the latest additions as RSS 2.0, or Atom with `format=atom`. Conditional
+// requests are answered with 304 until the catalog changes.
+func h_FeedLatest(response Response, request Request) {
+	var format string = getFeedFormat(request)
+
+	if format != FEED_FORMAT_RSS && format != FEED_FORMAT_ATOM {
+		writeJsonError(response, HTTP.StatusBadRequest, "Invalid `format` parameter, it must be 'rss' or 'atom'")
+		return
+	}
+
+	filter, err := getQueryMovieFilter(request)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := getQueryInt(request, "limit", DEFAULT_FEED_LIMIT)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit = min(max(limit, 1), MAXIMUM_FEED_LIMIT)
+
+	var preferMagnet bool = strings.EqualFold(request.URL.Query().Get("enclosure"), "magnet")
+
+	var modified time.Time = Catalog.GetLastModified()
+	var etag string = getFeedETag(modified, request, format)
+
+	response.Header().Set("ETag", etag)
+	response.Header().Set("Last-Modified", modified.UTC().Format(HTTP.TimeFormat))
+	response.Header().Set("Cache-Control", "public, max-age=60")
+
+	if isFeedNotModified(request, etag, modified) {
+		response.WriteHeader(HTTP.StatusNotModified)
+		return
+	}
+
+	var movies []*Catalog.LatestMovie = Catalog.GetLatestMovies(filter, limit)
+
+	var data any = nil
+	var contentType string = ""
+
+	if format == FEED_FORMAT_ATOM {
+		data = newAtomFeed(request, movies, modified, filter.Quality, preferMagnet)
+		contentType = "application/atom+xml; charset=utf-8"
+	} else {
+		data = newRSSFeed(request, movies, modified, filter.Quality, preferMagnet)
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+
+	body, err := xml.Marshal(data)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusInternalServerError, fmt.Sprintf("Couldn't encode feed: %s", err.Error()))
+		return
+	}
+
+	response.Header().Set("Content-Type", contentType)
+	response.WriteHeader(HTTP.StatusOK)
+
+	response.Write([]byte(xml.Header))
+	response.Write(body)
+}
@@ -1,23 +1,260 @@
 package HttpServer
 
 import (
+	"GServer/Catalog"
+	"GServer/Movie"
+	"encoding/json"
 	"fmt"
 	HTTP "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DEFAULT_MOVIES_LIST_LIMIT = 20
+	MAXIMUM_MOVIES_LIST_LIMIT = 50
+
+	MAXIMUM_PAGE_NUMBER = 1000000
 )
 
 type Response = HTTP.ResponseWriter
 type Request = *HTTP.Request
 
-var ADD_NUMBER int = 0
+func writeJson(response Response, status int, data any) {
+	response.Header().Set("Content-Type", "application/json; charset=utf-8")
+	response.WriteHeader(status)
+
+	json.NewEncoder(response).Encode(data)
+}
+
+func writeJsonError(response Response, status int, message string) {
+	writeApiError(response, status, newApiError(getApiErrorCode(status), message, ""))
+}
+
+func getQueryInt(request Request, name string, defaultValue int) (int, error) {
+	var value string = request.URL.Query().Get(name)
+
+	if len(value) < 1 {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("Invalid `%s` parameter, it must be an integer", name)
+	}
+
+	return number, nil
+}
+
+func getQueryFloat(request Request, name string, defaultValue float64) (float64, error) {
+	var value string = request.URL.Query().Get(name)
+
+	if len(value) < 1 {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("Invalid `%s` parameter, it must be a number", name)
+	}
+
+	return number, nil
+}
+
+func getQueryMovieFilter(request Request) (*Catalog.MovieFilter, error) {
+	var query url.Values = request.URL.Query()
+	var filter *Catalog.MovieFilter = Catalog.NewMovieFilter()
+
+	filter.Genre = query.Get("genre")
+	filter.Quality = query.Get("quality")
+	filter.Language = query.Get("language")
+	filter.MPARating = query.Get("mpa_rating")
+	filter.Source = query.Get("source")
+
+	var err error = nil
+
+	if filter.MinimumRating, err = getQueryFloat(request, "minimum_rating", 0); err != nil {
+		return nil, err
+	}
+
+	if filter.YearFrom, err = getQueryFloat(request, "year_from", 0); err != nil {
+		return nil, err
+	}
+
+	if filter.YearTo, err = getQueryFloat(request, "year_to", 0); err != nil {
+		return nil, err
+	}
+
+	if filter.Decade, err = getQueryFloat(request, "decade", 0); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// Returns the slice bounds of `page` in a list of `length` items. Pages past the
+// end are empty, huge page numbers don't overflow.
+func getPageRange(page int, limit int, length int) (int, int) {
+	if page < 1 || limit < 1 || page-1 > length/limit {
+		return length, length
+	}
+
+	var start int = min((page-1)*limit, length)
+
+	return start, min(start+limit, length)
+}
 
 func h_NotFound(response Response, request Request) {
-	response.WriteHeader(404)
+	writeJsonError(response, HTTP.StatusNotFound, "No endpoint at '"+request.URL.Path+"', see /openapi.json")
+}
+
+func h_Movies(response Response, request Request) {
+	filter, err := getQueryMovieFilter(request)
 
-	fmt.Fprintln(response, "Not Found")
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := getQueryInt(request, "page", 1)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := getQueryInt(request, "limit", DEFAULT_MOVIES_LIST_LIMIT)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	page = max(page, 1)
+	limit = min(max(limit, 1), MAXIMUM_MOVIES_LIST_LIMIT)
+
+	var catalogMovies []*Movie.MovieDetails = Catalog.GetMovies()
+	var movies []*Movie.MovieDetails = Catalog.FilterMovies(catalogMovies, filter)
+
+	start, end := getPageRange(page, limit, len(movies))
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"movie_count": len(movies),
+			"page_number": page,
+			"limit":       limit,
+			"movies":      movies[start:end],
+			"facets":      Catalog.GetMovieFacets(catalogMovies, filter),
+		},
+	})
 }
 
-func h_Add(response Response, request Request) {
-	defer fmt.Fprintln(response, "new add is : ", ADD_NUMBER)
+func h_Movie(response Response, request Request) {
+	var slug string = request.URL.Query().Get("slug")
+
+	if len(slug) < 1 {
+		writeJsonError(response, HTTP.StatusBadRequest, "Missing `slug` parameter")
+		return
+	}
+
+	var details *Movie.MovieDetails = Catalog.GetMovieBySlug(slug)
+
+	if details == nil {
+		writeJsonError(response, HTTP.StatusNotFound, "Movie not found")
+		return
+	}
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"movie": details,
+		},
+	})
+}
+
+func h_Torrent(response Response, request Request) {
+	var hash string = request.URL.Query().Get("hash")
+
+	if len(hash) < 1 {
+		writeJsonError(response, HTTP.StatusBadRequest, "Missing `hash` parameter")
+		return
+	}
+
+	var record *Catalog.TorrentRecord = Catalog.GetTorrentRecord(hash)
+
+	if record == nil {
+		writeJsonError(response, HTTP.StatusNotFound, "Torrent not found")
+		return
+	}
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"torrent": record,
+		},
+	})
+}
+
+func h_TorrentConflicts(response Response, request Request) {
+	var conflicts []*Catalog.TorrentRecord = Catalog.GetTorrentConflicts()
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"torrent_count": len(conflicts),
+			"torrents":      conflicts,
+		},
+	})
+}
+
+func h_Search(response Response, request Request) {
+	var query string = strings.TrimSpace(request.URL.Query().Get("q"))
+
+	if len(query) < 1 {
+		writeJsonError(response, HTTP.StatusBadRequest, "Missing `q` parameter")
+		return
+	}
+
+	filter, err := getQueryMovieFilter(request)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := getQueryInt(request, "page", 1)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := getQueryInt(request, "limit", DEFAULT_MOVIES_LIST_LIMIT)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	page = max(page, 1)
+	limit = min(max(limit, 1), MAXIMUM_MOVIES_LIST_LIMIT)
+
+	var results []*Catalog.SearchResult = Catalog.Search(query, filter)
+
+	start, end := getPageRange(page, limit, len(results))
 
-	ADD_NUMBER++
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data": map[string]any{
+			"query":       query,
+			"movie_count": len(results),
+			"page_number": page,
+			"limit":       limit,
+			"results":     results[start:end],
+		},
+	})
 }
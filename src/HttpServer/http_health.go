@@ -0,0 +1,422 @@
+package HttpServer
+
+import (
+	"GServer/Auth"
+	"GServer/Catalog"
+	"GServer/Config"
+	"GServer/Crawler"
+	"GServer/Defaults"
+	"GServer/TaskManager"
+	"context"
+	"fmt"
+	"io"
+	HTTP "net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HEALTH_STATUS_OK       = "ok"
+	HEALTH_STATUS_DEGRADED = "degraded"
+	HEALTH_STATUS_FAILED   = "failed"
+
+	// Upstream probes are cached so health checks don't hammer the services.
+	HEALTH_UPSTREAM_CHECK_INTERVAL = time.Minute * 1
+	HEALTH_UPSTREAM_CHECK_TIMEOUT  = time.Second * 5
+
+	// Queued tasks a manager may hold before it's reported as backed up.
+	HEALTH_TASK_BACKLOG_THRESHOLD = 1000
+)
+
+type HealthCheck struct {
+	Name   string
+	Status string
+
+	Message string
+
+	Details map[string]any
+}
+
+type HealthReport struct {
+	Status string
+	Ready  bool
+
+	StartedAt time.Time
+	Uptime    string
+
+	Checks []*HealthCheck
+}
+
+type upstreamStatus struct {
+	Reachable  bool
+	StatusCode int
+	Error      string
+
+	Latency time.Duration
+
+	CheckedAt time.Time
+}
+
+var StartedAt time.Time = time.Time{}
+
+// Set while the listener accepts connections.
+var Listening atomic.Bool
+
+var upstreamStatuses map[string]*upstreamStatus = map[string]*upstreamStatus{}
+
+// Upstreams with a probe in flight, so only one runs per upstream at a time.
+var upstreamProbes map[string]bool = map[string]bool{}
+var upstreamMutex sync.Mutex
+
+func newHealthCheck(name string) *HealthCheck {
+	var check *HealthCheck = new(HealthCheck)
+
+	check.Name = name
+	check.Status = HEALTH_STATUS_OK
+
+	check.Message = ""
+
+	check.Details = map[string]any{}
+
+	return check
+}
+
+func checkConfig() *HealthCheck {
+	var check *HealthCheck = newHealthCheck("config")
+
+	loadedAt, loadError := Config.GetLoadState()
+
+	check.Details["LoadedAt"] = loadedAt
+
+	if loadError != nil {
+		check.Status = HEALTH_STATUS_FAILED
+		check.Message = loadError.Error()
+
+		return check
+	}
+
+	// A rejected reload leaves the previous config running.
+	if reloadErrorAt, reloadError := Config.GetReloadState(); reloadError != nil {
+		check.Status = HEALTH_STATUS_DEGRADED
+		check.Message = "Config reload rejected, running the previous config: " + reloadError.Error()
+		check.Details["ReloadRejectedAt"] = reloadErrorAt
+	}
+
+	return check
+}
+
+func checkListener() *HealthCheck {
+	var check *HealthCheck = newHealthCheck("http_listener")
+
+	check.Details["Address"] = Config.Get().HttpHostAddress
+	check.Details["TLS"] = Certificate != nil
+
+	if !Listening.Load() {
+		check.Status = HEALTH_STATUS_FAILED
+		check.Message = "Not accepting connections"
+	} else if Context.Err() != nil {
+		check.Status = HEALTH_STATUS_FAILED
+		check.Message = "Shutting down"
+	}
+
+	return check
+}
+
+func checkCatalog() *HealthCheck {
+	var check *HealthCheck = newHealthCheck("catalog")
+
+	if !Catalog.IsInitialized() {
+		check.Status = HEALTH_STATUS_FAILED
+		check.Message = "Catalog isn't initialized"
+
+		return check
+	}
+
+	check.Details["MovieCount"] = Catalog.GetMovieCount()
+	check.Details["LastModified"] = Catalog.GetLastModified()
+
+	return check
+}
+
+// A crawler that failed leaves the catalog usable, so it only degrades health.
+func checkCrawler(name string, client *Crawler.Client, enabled bool) *HealthCheck {
+	var check *HealthCheck = newHealthCheck(name)
+
+	check.Details["Enabled"] = enabled
+
+	if client == nil {
+		if enabled {
+			check.Status = HEALTH_STATUS_DEGRADED
+			check.Message = "Crawler isn't initialized"
+		}
+
+		return check
+	}
+
+	var status *Crawler.ClientStatus = client.GetStatus()
+
+	check.Details["State"] = status.State
+	check.Details["CurrentPage"] = status.CurrentPage
+	check.Details["TotalMovies"] = status.TotalMovies
+
+	if !status.LastPageTime.IsZero() {
+		check.Details["LastPageTime"] = status.LastPageTime
+	}
+
+	if status.State == Crawler.CRAWLER_STATE_FAILED {
+		check.Status = HEALTH_STATUS_DEGRADED
+		check.Message = status.LastError
+	}
+
+	return check
+}
+
+func probeUpstream(url string) *upstreamStatus {
+	var status *upstreamStatus = new(upstreamStatus)
+
+	status.Reachable = false
+	status.StatusCode = 0
+	status.Error = ""
+
+	status.CheckedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(Context, HEALTH_UPSTREAM_CHECK_TIMEOUT)
+	defer cancel()
+
+	request, err := HTTP.NewRequestWithContext(ctx, HTTP.MethodGet, url, nil)
+
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	response, err := HTTP.DefaultClient.Do(request)
+
+	status.Latency = time.Since(status.CheckedAt)
+
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	status.StatusCode = response.StatusCode
+	status.Reachable = response.StatusCode < 500
+
+	if !status.Reachable {
+		status.Error = fmt.Sprintf("Responded with status %d", response.StatusCode)
+	}
+
+	return status
+}
+
+// Returns the cached probe of `url`. Once it's stale and `refresh` is set, the
+// upstream is probed again in the background for the next caller, so health checks
+// never wait on a slow upstream.
+func getUpstreamStatus(url string, refresh bool) *upstreamStatus {
+	upstreamMutex.Lock()
+	defer upstreamMutex.Unlock()
+
+	var status *upstreamStatus = upstreamStatuses[url]
+
+	if !refresh || upstreamProbes[url] || (status != nil && time.Since(status.CheckedAt) < HEALTH_UPSTREAM_CHECK_INTERVAL) {
+		return status
+	}
+
+	upstreamProbes[url] = true
+
+	go func() {
+		var probed *upstreamStatus = probeUpstream(url)
+
+		upstreamMutex.Lock()
+		defer upstreamMutex.Unlock()
+
+		upstreamStatuses[url] = probed
+
+		delete(upstreamProbes, url)
+	}()
+
+	return status
+}
+
+// Unreachable upstreams only stop the crawlers, the API keeps serving the catalog.
+func checkUpstream(name string, url string, enabled bool, refresh bool) *HealthCheck {
+	var check *HealthCheck = newHealthCheck(name)
+
+	check.Details["URL"] = url
+	check.Details["Enabled"] = enabled
+
+	if !enabled {
+		return check
+	}
+
+	var status *upstreamStatus = getUpstreamStatus(url, refresh)
+
+	if status == nil {
+		check.Message = "Not checked yet"
+
+		return check
+	}
+
+	check.Details["StatusCode"] = status.StatusCode
+	check.Details["Latency"] = status.Latency.String()
+	check.Details["CheckedAt"] = status.CheckedAt
+
+	if !status.Reachable {
+		check.Status = HEALTH_STATUS_DEGRADED
+		check.Message = status.Error
+	}
+
+	return check
+}
+
+func checkTaskBacklog() *HealthCheck {
+	var check *HealthCheck = newHealthCheck("task_managers")
+
+	var backedUp []string = []string{}
+
+	for _, status := range TaskManager.GetTaskManagerStatuses() {
+		check.Details[status.Name] = map[string]any{
+			"PendingTasks":   status.PendingTasks,
+			"RunningTasks":   status.RunningTasks,
+			"MaximumThreads": status.MaximumThreads,
+			"Paused":         status.Paused,
+		}
+
+		if status.PendingTasks > HEALTH_TASK_BACKLOG_THRESHOLD {
+			backedUp = append(backedUp, status.Name)
+		}
+	}
+
+	if len(backedUp) > 0 {
+		check.Status = HEALTH_STATUS_DEGRADED
+		check.Message = fmt.Sprintf("Backlog over %d tasks: %v", HEALTH_TASK_BACKLOG_THRESHOLD, backedUp)
+	}
+
+	return check
+}
+
+func runHealthChecks(checks []func() *HealthCheck) []*HealthCheck {
+	var results []*HealthCheck = make([]*HealthCheck, len(checks))
+	var group sync.WaitGroup
+
+	for index, check := range checks {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			results[index] = check()
+		}()
+	}
+
+	group.Wait()
+
+	return results
+}
+
+// Upstreams are reported from their last probe, `refreshUpstreams` probes the stale
+// ones again in the background.
+func GetHealthReport(refreshUpstreams bool) *HealthReport {
+	var report *HealthReport = new(HealthReport)
+
+	report.StartedAt = StartedAt
+	report.Uptime = time.Since(StartedAt).Truncate(time.Second).String()
+
+	report.Checks = runHealthChecks([]func() *HealthCheck{
+		checkConfig,
+		checkListener,
+		checkCatalog,
+		func() *HealthCheck {
+			return checkCrawler("crawler_yts", Crawler.YTSCrawler, Config.Get().CanUseYTSService)
+		},
+		func() *HealthCheck {
+			return checkCrawler("crawler_internet_archive", Crawler.InternetArchiveCrawler, Config.Get().CanUseInternetArchiveService)
+		},
+		func() *HealthCheck {
+			return checkUpstream("upstream_yts", Defaults.YTS_API_BASE_URL+Defaults.YTS_API_LIST_MOVIES_ENDPOINT+"?limit=1", Config.Get().CanUseYTSService, refreshUpstreams)
+		},
+		func() *HealthCheck {
+			return checkUpstream("upstream_internet_archive", Defaults.INTERNET_ARCHIVE_BASE_URL, Config.Get().CanUseInternetArchiveService, refreshUpstreams)
+		},
+		checkTaskBacklog,
+	})
+
+	report.Status = HEALTH_STATUS_OK
+
+	for _, check := range report.Checks {
+		if check.Status == HEALTH_STATUS_FAILED {
+			report.Status = HEALTH_STATUS_FAILED
+			break
+		}
+
+		if check.Status == HEALTH_STATUS_DEGRADED {
+			report.Status = HEALTH_STATUS_DEGRADED
+		}
+	}
+
+	report.Ready = report.Status != HEALTH_STATUS_FAILED
+
+	return report
+}
+
+// Checks carry error messages and config details, so with API keys enabled only
+// admin keys see them and everyone else gets the overall status.
+func canSeeHealthChecks(request Request) bool {
+	if !Auth.Enabled {
+		return true
+	}
+
+	key, err := Auth.Authenticate(getRequestApiKey(request))
+
+	return err == nil && key.HasScope(Auth.SCOPE_ADMIN)
+}
+
+func getHealthResponseData(report *HealthReport, request Request) any {
+	if canSeeHealthChecks(request) {
+		return report
+	}
+
+	return map[string]any{
+		"Status": report.Status,
+		"Ready":  report.Ready,
+	}
+}
+
+// Liveness, answers 200 as long as the process serves requests. Admins get every
+// check, with the last upstream probes, so they can see what's degraded.
+func h_Healthz(response Response, request Request) {
+	response.Header().Set("Cache-Control", "no-store")
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data":   getHealthResponseData(GetHealthReport(false), request),
+	})
+}
+
+// Readiness, answers 503 while a check the API depends on has failed or the
+// server is draining. Upstream probes are refreshed in the background.
+func h_Readyz(response Response, request Request) {
+	var report *HealthReport = GetHealthReport(true)
+
+	response.Header().Set("Cache-Control", "no-store")
+
+	if !report.Ready {
+		writeJson(response, HTTP.StatusServiceUnavailable, map[string]any{
+			"status":         "error",
+			"status_message": "Not ready",
+			"data":           getHealthResponseData(report, request),
+		})
+
+		return
+	}
+
+	writeJson(response, HTTP.StatusOK, map[string]any{
+		"status": "ok",
+		"data":   getHealthResponseData(report, request),
+	})
+}
@@ -0,0 +1,109 @@
+package HttpServer
+
+import (
+	"GServer/Logger"
+	"GServer/Metrics"
+	"GServer/TaskManager"
+	"bufio"
+	"net"
+	HTTP "net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Parsers create a task manager per request, named after the base name and a pointer.
+var taskManagerSuffix *regexp.Regexp = regexp.MustCompile(`_[0-9]+$`)
+
+// Records the status written by a handler. Streaming handlers still reach the
+// underlying writer's Flusher and Hijacker.
+type statusRecorder struct {
+	Response
+
+	Status int
+}
+
+func newStatusRecorder(response Response) *statusRecorder {
+	var recorder *statusRecorder = new(statusRecorder)
+
+	recorder.Response = response
+
+	recorder.Status = 0
+
+	return recorder
+}
+
+func (this *statusRecorder) WriteHeader(status int) {
+	if this.Status == 0 {
+		this.Status = status
+	}
+
+	this.Response.WriteHeader(status)
+}
+
+func (this *statusRecorder) Write(data []byte) (int, error) {
+	if this.Status == 0 {
+		this.Status = HTTP.StatusOK
+	}
+
+	return this.Response.Write(data)
+}
+
+func (this *statusRecorder) Flush() {
+	if flusher, ok := this.Response.(HTTP.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (this *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	// Upgraded connections answer with 101 Switching Protocols.
+	this.Status = HTTP.StatusSwitchingProtocols
+
+	return HTTP.NewResponseController(this.Response).Hijack()
+}
+
+func (this *statusRecorder) Unwrap() Response {
+	return this.Response
+}
+
+func observeRequest(endpoint *ApiEndpoint, request Request, status int, started time.Time) {
+	if status == 0 {
+		status = HTTP.StatusOK
+	}
+
+	var duration time.Duration = time.Since(started)
+
+	Metrics.HttpRequests.Inc(endpoint.Path, request.Method, strconv.Itoa(status))
+	Metrics.HttpRequestDuration.Observe(duration.Seconds(), endpoint.Path, request.Method)
+
+	Logger.DEBUG_CONTEXT(request.Context(), "Served request.", "method", request.Method, "path", request.URL.Path, "status", status, "duration", duration)
+}
+
+func updateTaskManagerMetrics() {
+	var pending map[string]int = map[string]int{}
+	var running map[string]int = map[string]int{}
+
+	for _, status := range TaskManager.GetTaskManagerStatuses() {
+		var name string = taskManagerSuffix.ReplaceAllString(status.Name, "")
+
+		pending[name] += status.PendingTasks
+		running[name] += status.RunningTasks
+	}
+
+	Metrics.TaskManagerPendingTasks.Reset()
+	Metrics.TaskManagerRunningTasks.Reset()
+
+	for name, count := range pending {
+		Metrics.TaskManagerPendingTasks.Set(float64(count), name)
+		Metrics.TaskManagerRunningTasks.Set(float64(running[name]), name)
+	}
+}
+
+func h_Metrics(response Response, request Request) {
+	updateTaskManagerMetrics()
+
+	response.Header().Set("Content-Type", Metrics.TEXT_FORMAT_CONTENT_TYPE)
+	response.WriteHeader(HTTP.StatusOK)
+
+	Metrics.WriteText(response)
+}
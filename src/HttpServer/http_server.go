@@ -2,48 +2,200 @@ package HttpServer
 
 import (
 	"GServer/Config"
+	"GServer/Defaults"
 	"GServer/Logger"
 	"GServer/TaskManager"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	HTTP "net/http"
 	"time"
 )
 
 var Tasks *TaskManager.TaskManager = nil
 
-func serverListen(serverHostAddress string) {
-	err := HTTP.ListenAndServe(serverHostAddress, nil)
+var Settings Config.ConfigHttpServer = Config.ConfigHttpServer{}
 
-	if err == nil {
+var Router *HTTP.ServeMux = nil
+var Server *HTTP.Server = nil
+
+var Certificate *CertificateReloader = nil
+
+// Cancelled when the server shuts down so streaming handlers let go of their
+// connections instead of holding up the drain.
+var Context context.Context = nil
+var ContextCancel context.CancelFunc = nil
+
+func loadSettings() {
+	Settings = Config.Get().HttpServer
+
+	if Settings.ReadTimeout <= 0 {
+		Settings.ReadTimeout = Defaults.HTTP_SERVER_READ_TIMEOUT
+	}
+
+	if Settings.ReadHeaderTimeout <= 0 {
+		Settings.ReadHeaderTimeout = Defaults.HTTP_SERVER_READ_HEADER_TIMEOUT
+	}
+
+	if Settings.WriteTimeout <= 0 {
+		Settings.WriteTimeout = Defaults.HTTP_SERVER_WRITE_TIMEOUT
+	}
+
+	if Settings.IdleTimeout <= 0 {
+		Settings.IdleTimeout = Defaults.HTTP_SERVER_IDLE_TIMEOUT
+	}
+
+	if Settings.ShutdownTimeout <= 0 {
+		Settings.ShutdownTimeout = Defaults.HTTP_SERVER_SHUTDOWN_TIMEOUT
+	}
+
+	if Settings.MaxHeaderBytes < 1 {
+		Settings.MaxHeaderBytes = Defaults.HTTP_SERVER_MAX_HEADER_BYTES
+	}
+
+	if Settings.TLSReloadInterval <= 0 {
+		Settings.TLSReloadInterval = Defaults.HTTP_SERVER_TLS_RELOAD_INTERVAL
+	}
+}
+
+func newRouter() *HTTP.ServeMux {
+	var router *HTTP.ServeMux = HTTP.NewServeMux()
+
+	ApiEndpoints = newApiEndpoints()
+
+	router.HandleFunc("/", h_NotFound)
+
+	for _, endpoint := range ApiEndpoints {
+		router.Handle(endpoint.Path, endpoint)
+	}
+
+	return router
+}
+
+func newServer(serverHostAddress string, router *HTTP.ServeMux) *HTTP.Server {
+	var server *HTTP.Server = new(HTTP.Server)
+
+	server.Addr = serverHostAddress
+	server.Handler = router
+
+	server.ReadTimeout = Settings.ReadTimeout
+	server.ReadHeaderTimeout = Settings.ReadHeaderTimeout
+	server.WriteTimeout = Settings.WriteTimeout
+	server.IdleTimeout = Settings.IdleTimeout
+
+	server.MaxHeaderBytes = Settings.MaxHeaderBytes
+
+	server.RegisterOnShutdown(ContextCancel)
+
+	return server
+}
+
+func isTLSEnabled() bool {
+	return len(Settings.TLSCertificateFile) > 0 && len(Settings.TLSKeyFile) > 0
+}
+
+func serverListen(server *HTTP.Server, listener net.Listener) {
+	var err error = nil
+
+	Listening.Store(true)
+
+	if server.TLSConfig != nil {
+		err = server.ServeTLS(listener, "", "")
+	} else {
+		err = server.Serve(listener)
+	}
+
+	Listening.Store(false)
+
+	if err == nil || errors.Is(err, HTTP.ErrServerClosed) {
 		return
 	}
 
-	Logger.ERROR("Couldn't start HTTP server. [Mesasge: ", err.Error(), "]")
+	Logger.ERROR("HTTP server stopped serving requests.", "error", err)
 }
 
-func Initialize() {
-	var serverHostAddress string = Config.Main.HttpHostAddress
+// Binds the listener and loads the TLS certificate before returning, so a taken
+// address or a broken certificate fails startup instead of leaving the process
+// running without an API or without TLS.
+func Initialize() error {
+	var serverHostAddress string = Config.Get().HttpHostAddress
+
+	Logger.INFO("Starting HTTP server...", "address", serverHostAddress)
+
+	loadSettings()
+
+	listener, err := net.Listen("tcp", serverHostAddress)
+
+	if err != nil {
+		return fmt.Errorf("Couldn't listen on '%s': %w", serverHostAddress, err)
+	}
+
+	// Falling back to plain HTTP would quietly drop the encryption clients expect.
+	var reloader *CertificateReloader = nil
 
-	Logger.INFO("Starting HTTP server on '" + serverHostAddress + "' ...")
+	if isTLSEnabled() {
+		reloader, err = NewCertificateReloader(Settings.TLSCertificateFile, Settings.TLSKeyFile)
 
-	Tasks = TaskManager.CreateTaskManager("HTTP_SERVER", TaskManager.UNLIMITED_THREAD_COUNT)
+		if err != nil {
+			listener.Close()
 
-	HTTP.HandleFunc("/", h_NotFound)
-	HTTP.HandleFunc("/add", h_Add)
+			return fmt.Errorf("Couldn't load TLS certificate '%s': %w", Settings.TLSCertificateFile, err)
+		}
+	}
+
+	StartedAt = time.Now()
+
+	Context, ContextCancel = context.WithCancel(TaskManager.MainContext)
+
+	Tasks = TaskManager.CreateTaskManagerWithContext(Context, "HTTP_SERVER", TaskManager.UNLIMITED_THREAD_COUNT)
+
+	Router = newRouter()
+	Server = newServer(serverHostAddress, Router)
+
+	if reloader != nil {
+		Certificate = reloader
+
+		Server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: Certificate.GetCertificate,
+		}
+
+		Tasks.AddTask(func(task *TaskManager.Task) {
+			Certificate.Watch(Context.Done(), Settings.TLSReloadInterval)
+		})
+	}
+
+	var server *HTTP.Server = Server
 
 	Tasks.AddTask(func(task *TaskManager.Task) {
-		serverListen(serverHostAddress)
+		serverListen(server, listener)
 	})
 
 	Tasks.Start()
 
-	time.Sleep(time.Second * 1)
+	Logger.INFO("HTTP server started and listening to requests.", "address", listener.Addr().String())
 
-	Logger.INFO("HTTP server started and listening to requests on '" + serverHostAddress + "'.")
+	return nil
 }
 
+// Stops accepting connections and waits up to the shutdown timeout for running
+// requests to finish before closing the rest.
 func Uninitialize() {
 	Logger.INFO("Uninitializing HTTP server ...")
 
+	ctx, cancel := context.WithTimeout(context.Background(), Settings.ShutdownTimeout)
+	defer cancel()
+
+	if err := Server.Shutdown(ctx); err != nil {
+		Logger.WARN("HTTP server didn't drain in time, closing remaining connections.", "error", err)
+
+		Server.Close()
+	}
+
+	ContextCancel()
+
 	TaskManager.DeleteTaskManager(Tasks.Name)
 
 	Logger.INFO("HTTP server uninitialized.")
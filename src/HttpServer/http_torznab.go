@@ -0,0 +1,480 @@
+package HttpServer
+
+import (
+	"GServer/Catalog"
+	"GServer/Movie"
+	"encoding/xml"
+	"fmt"
+	HTTP "net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TORZNAB_NAMESPACE = "http://torznab.com/schemas/2015/feed"
+
+	TORZNAB_DEFAULT_LIMIT = 50
+	TORZNAB_MAXIMUM_LIMIT = 100
+
+	TORZNAB_ERROR_INCORRECT_CREDENTIALS = 100
+	TORZNAB_ERROR_INSUFFICIENT_RIGHTS   = 102
+	TORZNAB_ERROR_MISSING_PARAMETER     = 200
+	TORZNAB_ERROR_INCORRECT_PARAMETER   = 201
+	TORZNAB_ERROR_NO_SUCH_FUNCTION      = 202
+	TORZNAB_ERROR_REQUEST_LIMIT_REACHED = 500
+	TORZNAB_ERROR_UNKNOWN               = 900
+
+	TORZNAB_CATEGORY_MOVIES       = 2000
+	TORZNAB_CATEGORY_MOVIES_OTHER = 2020
+	TORZNAB_CATEGORY_MOVIES_SD    = 2030
+	TORZNAB_CATEGORY_MOVIES_HD    = 2040
+	TORZNAB_CATEGORY_MOVIES_UHD   = 2045
+	TORZNAB_CATEGORY_MOVIES_3D    = 2060
+)
+
+type TorznabCategory struct {
+	Id   int    `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+
+	Subcategories []*TorznabCategory `xml:"subcat,omitempty"`
+}
+
+type TorznabSearchCapability struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type TorznabCapabilities struct {
+	XMLName xml.Name `xml:"caps"`
+
+	Server struct {
+		Title string `xml:"title,attr"`
+	} `xml:"server"`
+
+	Limits struct {
+		Maximum int `xml:"max,attr"`
+		Default int `xml:"default,attr"`
+	} `xml:"limits"`
+
+	Searching struct {
+		Search      TorznabSearchCapability `xml:"search"`
+		TVSearch    TorznabSearchCapability `xml:"tv-search"`
+		MovieSearch TorznabSearchCapability `xml:"movie-search"`
+	} `xml:"searching"`
+
+	Categories []*TorznabCategory `xml:"categories>category"`
+}
+
+type TorznabAttribute struct {
+	XMLName xml.Name `xml:"torznab:attr"`
+
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type TorznabEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type TorznabGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type TorznabItem struct {
+	Title    string      `xml:"title"`
+	Guid     TorznabGuid `xml:"guid"`
+	Link     string      `xml:"link"`
+	PubDate  string      `xml:"pubDate"`
+	Size     int64       `xml:"size"`
+	Category []int       `xml:"category"`
+
+	Enclosure TorznabEnclosure `xml:"enclosure"`
+
+	Attributes []*TorznabAttribute
+}
+
+type TorznabFeed struct {
+	XMLName xml.Name `xml:"rss"`
+
+	Version          string `xml:"version,attr"`
+	TorznabNamespace string `xml:"xmlns:torznab,attr"`
+
+	Channel struct {
+		Title       string `xml:"title"`
+		Description string `xml:"description"`
+		Link        string `xml:"link"`
+
+		Response struct {
+			XMLName xml.Name `xml:"torznab:response"`
+
+			Offset int `xml:"offset,attr"`
+			Total  int `xml:"total,attr"`
+		}
+
+		Items []*TorznabItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type TorznabError struct {
+	XMLName xml.Name `xml:"error"`
+
+	Code        int    `xml:"code,attr"`
+	Description string `xml:"description,attr"`
+}
+
+var TorznabCategories []*TorznabCategory = []*TorznabCategory{
+	{
+		Id:   TORZNAB_CATEGORY_MOVIES,
+		Name: "Movies",
+		Subcategories: []*TorznabCategory{
+			{Id: TORZNAB_CATEGORY_MOVIES_OTHER, Name: "Movies/Other"},
+			{Id: TORZNAB_CATEGORY_MOVIES_SD, Name: "Movies/SD"},
+			{Id: TORZNAB_CATEGORY_MOVIES_HD, Name: "Movies/HD"},
+			{Id: TORZNAB_CATEGORY_MOVIES_UHD, Name: "Movies/UHD"},
+			{Id: TORZNAB_CATEGORY_MOVIES_3D, Name: "Movies/3D"},
+		},
+	},
+}
+
+var imdbIdExpression *regexp.Regexp = regexp.MustCompile(`^(?i:tt)?(\d{1,10})$`)
+
+// Torznab uses the digits of IMDb ids, "tt0133093" is "0133093".
+func getTorznabImdbId(value string) string {
+	var matches []string = imdbIdExpression.FindStringSubmatch(strings.TrimSpace(value))
+
+	if matches == nil {
+		return ""
+	}
+
+	number, err := strconv.Atoi(matches[1])
+
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%07d", number)
+}
+
+func writeXml(response Response, status int, data any) {
+	response.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	response.WriteHeader(status)
+
+	response.Write([]byte(xml.Header))
+
+	xml.NewEncoder(response).Encode(data)
+}
+
+// Torznab clients read errors from the document, not the status code.
+func writeTorznabError(response Response, code int, description string) {
+	var torznabError *TorznabError = new(TorznabError)
+
+	torznabError.Code = code
+	torznabError.Description = description
+
+	writeXml(response, HTTP.StatusOK, torznabError)
+}
+
+func GetTorznabCategory(torrent *Movie.MovieTorrentInfo) int {
+	switch strings.ToLower(torrent.Quality) {
+	case "3d":
+		return TORZNAB_CATEGORY_MOVIES_3D
+	case "2160p", "4k":
+		return TORZNAB_CATEGORY_MOVIES_UHD
+	case "1080p", "720p":
+		return TORZNAB_CATEGORY_MOVIES_HD
+	case "576p", "480p", "360p":
+		return TORZNAB_CATEGORY_MOVIES_SD
+	}
+
+	return TORZNAB_CATEGORY_MOVIES_OTHER
+}
+
+func getTorznabPubDate(details *Movie.MovieDetails, torrent *Movie.MovieTorrentInfo) string {
+	var date time.Time = time.Time{}
+
+	if torrent.DateUploadedUnix > 0 {
+		date = time.Unix(int64(torrent.DateUploadedUnix), 0)
+	} else if details.DateUploadedUnix > 0 {
+		date = time.Unix(int64(details.DateUploadedUnix), 0)
+	} else if parsed, err := time.Parse(time.RFC3339, details.DateUploaded); err == nil {
+		date = parsed
+	} else if parsed, err := time.Parse(time.DateTime, details.DateUploaded); err == nil {
+		date = parsed
+	} else {
+		date = time.Unix(0, 0)
+	}
+
+	return date.UTC().Format(time.RFC1123Z)
+}
+
+func getTorznabItemTitle(details *Movie.MovieDetails, torrent *Movie.MovieTorrentInfo) string {
+	if len(torrent.Name) > 0 && torrent.Source != Movie.MOVIE_SOURCE_YTS {
+		return torrent.Name
+	}
+
+	// YTS torrent names are bare titles, build a release name indexers can parse.
+	var parts []string = []string{details.CleanTitle}
+
+	if details.Year > 0 {
+		parts = append(parts, fmt.Sprintf("(%.0f)", details.Year))
+	}
+
+	for _, part := range []string{torrent.Quality, torrent.Type, torrent.VideoCodec} {
+		if len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+
+	if len(torrent.Source) > 0 {
+		parts = append(parts, "["+torrent.Source+"]")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func newTorznabAttribute(name string, value string) *TorznabAttribute {
+	var attribute *TorznabAttribute = new(TorznabAttribute)
+
+	attribute.Name = name
+	attribute.Value = value
+
+	return attribute
+}
+
+func newTorznabItem(details *Movie.MovieDetails, torrent *Movie.MovieTorrentInfo) *TorznabItem {
+	var item *TorznabItem = new(TorznabItem)
+
+	var category int = GetTorznabCategory(torrent)
+
+	item.Title = getTorznabItemTitle(details, torrent)
+
+	item.Guid.IsPermaLink = false
+	item.Guid.Value = strings.ToLower(torrent.Hash)
+
+	if len(item.Guid.Value) < 1 {
+		item.Guid.Value = torrent.URL
+	}
+
+	item.Link = torrent.URL
+	item.PubDate = getTorznabPubDate(details, torrent)
+	item.Size = int64(torrent.Size)
+
+	item.Category = []int{TORZNAB_CATEGORY_MOVIES, category}
+
+	item.Enclosure.URL = torrent.URL
+	item.Enclosure.Length = item.Size
+	item.Enclosure.Type = "application/x-bittorrent"
+
+	var seeders float64 = torrent.Seeds
+	var peers float64 = torrent.Seeds + torrent.Peers
+
+	item.Attributes = []*TorznabAttribute{
+		newTorznabAttribute("category", strconv.Itoa(TORZNAB_CATEGORY_MOVIES)),
+		newTorznabAttribute("category", strconv.Itoa(category)),
+		newTorznabAttribute("size", strconv.FormatInt(item.Size, 10)),
+		newTorznabAttribute("seeders", strconv.FormatFloat(seeders, 'f', 0, 64)),
+		newTorznabAttribute("peers", strconv.FormatFloat(peers, 'f', 0, 64)),
+		newTorznabAttribute("downloadvolumefactor", "1"),
+		newTorznabAttribute("uploadvolumefactor", "1"),
+	}
+
+	if len(torrent.Hash) > 0 {
+		item.Attributes = append(item.Attributes, newTorznabAttribute("infohash", strings.ToLower(torrent.Hash)))
+	}
+
+	if len(torrent.Magent) > 0 {
+		item.Attributes = append(item.Attributes, newTorznabAttribute("magneturl", torrent.Magent))
+	}
+
+	if imdbId := getTorznabImdbId(details.IMDBCode); len(imdbId) > 0 {
+		item.Attributes = append(item.Attributes, newTorznabAttribute("imdbid", imdbId))
+	}
+
+	if details.Year > 0 {
+		item.Attributes = append(item.Attributes, newTorznabAttribute("year", fmt.Sprintf("%.0f", details.Year)))
+	}
+
+	return item
+}
+
+func (this *TorznabItem) HasCategory(categories map[int]bool) bool {
+	if len(categories) < 1 {
+		return true
+	}
+
+	for _, category := range this.Category {
+		if categories[category] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getTorznabCategories(value string) (map[int]bool, error) {
+	var categories map[int]bool = map[int]bool{}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+
+		if len(part) < 1 {
+			continue
+		}
+
+		category, err := strconv.Atoi(part)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid category '%s'", part)
+		}
+
+		categories[category] = true
+	}
+
+	return categories, nil
+}
+
+func h_TorznabCaps(response Response) {
+	var capabilities *TorznabCapabilities = new(TorznabCapabilities)
+
+	capabilities.Server.Title = "GServer"
+
+	capabilities.Limits.Maximum = TORZNAB_MAXIMUM_LIMIT
+	capabilities.Limits.Default = TORZNAB_DEFAULT_LIMIT
+
+	capabilities.Searching.Search = TorznabSearchCapability{Available: "yes", SupportedParams: "q"}
+	capabilities.Searching.TVSearch = TorznabSearchCapability{Available: "no", SupportedParams: "q"}
+	capabilities.Searching.MovieSearch = TorznabSearchCapability{Available: "yes", SupportedParams: "q,imdbid"}
+
+	capabilities.Categories = TorznabCategories
+
+	writeXml(response, HTTP.StatusOK, capabilities)
+}
+
+// Movies matching the request, newest first when there is no query so indexer
+// managers polling the feed see recent additions.
+func getTorznabMovies(request Request, function string) ([]*Movie.MovieDetails, error) {
+	var query string = strings.TrimSpace(request.URL.Query().Get("q"))
+	var imdbId string = strings.TrimSpace(request.URL.Query().Get("imdbid"))
+
+	if function == "movie" && len(imdbId) > 0 {
+		var digits string = getTorznabImdbId(imdbId)
+
+		if len(digits) < 1 {
+			return nil, fmt.Errorf("Invalid imdbid '%s'", imdbId)
+		}
+
+		var details *Movie.MovieDetails = Catalog.GetMovieByIMDBCode("tt" + digits)
+
+		if details == nil {
+			return []*Movie.MovieDetails{}, nil
+		}
+
+		return []*Movie.MovieDetails{details}, nil
+	}
+
+	if len(query) > 0 {
+		var movies []*Movie.MovieDetails = []*Movie.MovieDetails{}
+
+		for _, result := range Catalog.Search(query, nil) {
+			movies = append(movies, result.Movie)
+		}
+
+		return movies, nil
+	}
+
+	var movies []*Movie.MovieDetails = Catalog.GetMovies()
+
+	for i, j := 0, len(movies)-1; i < j; i, j = i+1, j-1 {
+		movies[i], movies[j] = movies[j], movies[i]
+	}
+
+	return movies, nil
+}
+
+func h_TorznabSearch(response Response, request Request, function string) {
+	categories, err := getTorznabCategories(request.URL.Query().Get("cat"))
+
+	if err != nil {
+		writeTorznabError(response, TORZNAB_ERROR_INCORRECT_PARAMETER, err.Error())
+		return
+	}
+
+	offset, err := getQueryInt(request, "offset", 0)
+
+	if err != nil {
+		writeTorznabError(response, TORZNAB_ERROR_INCORRECT_PARAMETER, err.Error())
+		return
+	}
+
+	limit, err := getQueryInt(request, "limit", TORZNAB_DEFAULT_LIMIT)
+
+	if err != nil {
+		writeTorznabError(response, TORZNAB_ERROR_INCORRECT_PARAMETER, err.Error())
+		return
+	}
+
+	offset = max(offset, 0)
+	limit = min(max(limit, 1), TORZNAB_MAXIMUM_LIMIT)
+
+	movies, err := getTorznabMovies(request, function)
+
+	if err != nil {
+		writeTorznabError(response, TORZNAB_ERROR_INCORRECT_PARAMETER, err.Error())
+		return
+	}
+
+	var items []*TorznabItem = []*TorznabItem{}
+
+	for _, details := range movies {
+		for _, torrent := range details.Torrents {
+			if !Movie.IsMovieTorrentInfoValid(torrent) {
+				continue
+			}
+
+			var item *TorznabItem = newTorznabItem(details, torrent)
+
+			if item.HasCategory(categories) {
+				items = append(items, item)
+			}
+		}
+	}
+
+	var feed *TorznabFeed = new(TorznabFeed)
+
+	feed.Version = "2.0"
+	feed.TorznabNamespace = TORZNAB_NAMESPACE
+
+	feed.Channel.Title = "GServer"
+	feed.Channel.Description = "GServer movie catalog"
+	feed.Channel.Link = "http://" + request.Host + "/"
+
+	feed.Channel.Response.Offset = offset
+	feed.Channel.Response.Total = len(items)
+
+	var start int = min(offset, len(items))
+	var end int = min(start+limit, len(items))
+
+	feed.Channel.Items = items[start:end]
+
+	writeXml(response, HTTP.StatusOK, feed)
+}
+
+func h_Torznab(response Response, request Request) {
+	var function string = strings.ToLower(request.URL.Query().Get("t"))
+
+	switch function {
+	case "":
+		writeTorznabError(response, TORZNAB_ERROR_MISSING_PARAMETER, "Missing parameter (t)")
+	case "caps":
+		h_TorznabCaps(response)
+	case "search", "movie":
+		h_TorznabSearch(response, request, function)
+	default:
+		writeTorznabError(response, TORZNAB_ERROR_NO_SUCH_FUNCTION, "No such function ("+function+")")
+	}
+}
@@ -0,0 +1,214 @@
+package HttpServer
+
+import (
+	"GServer/Catalog"
+	"GServer/Events"
+	"GServer/Logger"
+	"GServer/Movie"
+	"encoding/json"
+	"errors"
+	HTTP "net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	WEBSOCKET_WRITE_TIMEOUT = time.Second * 10
+	WEBSOCKET_PONG_TIMEOUT  = time.Second * 60
+	WEBSOCKET_PING_INTERVAL = WEBSOCKET_PONG_TIMEOUT * 9 / 10
+
+	WEBSOCKET_MAXIMUM_MESSAGE_SIZE = 4096
+
+	WEBSOCKET_MESSAGE_TYPE_SUBSCRIBED = "subscribed"
+	WEBSOCKET_MESSAGE_TYPE_ERROR      = "error"
+)
+
+// Sent by clients to replace the filter of their feed, empty fields match everything.
+type MovieFeedSubscribeMessage struct {
+	Genre    string
+	Quality  string
+	Language string
+
+	MinimumRating float64
+}
+
+type MovieFeedMessage struct {
+	Type string
+
+	Movie  *Movie.MovieDetails
+	Filter *Catalog.MovieFilter
+
+	Message string
+}
+
+var websocketUpgrader websocket.Upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+func newMovieFeedMessage(messageType string) *MovieFeedMessage {
+	var message *MovieFeedMessage = new(MovieFeedMessage)
+
+	message.Type = messageType
+
+	message.Movie = nil
+	message.Filter = nil
+
+	message.Message = ""
+
+	return message
+}
+
+func writeWebSocketJson(connection *websocket.Conn, data any) error {
+	connection.SetWriteDeadline(time.Now().Add(WEBSOCKET_WRITE_TIMEOUT))
+
+	return connection.WriteJSON(data)
+}
+
+func readMovieFeedFilters(connection *websocket.Conn, filters chan<- *Catalog.MovieFilter, done chan<- bool, stop <-chan bool) {
+	defer close(done)
+
+	connection.SetReadLimit(WEBSOCKET_MAXIMUM_MESSAGE_SIZE)
+	connection.SetReadDeadline(time.Now().Add(WEBSOCKET_PONG_TIMEOUT))
+
+	connection.SetPongHandler(func(string) error {
+		return connection.SetReadDeadline(time.Now().Add(WEBSOCKET_PONG_TIMEOUT))
+	})
+
+	for {
+		var message MovieFeedSubscribeMessage
+		var filter *Catalog.MovieFilter = nil
+
+		err := connection.ReadJSON(&message)
+
+		if err == nil {
+			filter = Catalog.NewMovieFilter()
+
+			filter.Genre = message.Genre
+			filter.Quality = message.Quality
+			filter.Language = message.Language
+
+			filter.MinimumRating = message.MinimumRating
+		} else {
+			var syntaxError *json.SyntaxError
+			var typeError *json.UnmarshalTypeError
+
+			// Malformed messages are reported to the client, anything else closed the connection.
+			if !errors.As(err, &syntaxError) && !errors.As(err, &typeError) {
+				return
+			}
+		}
+
+		select {
+		case filters <- filter:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Pushes movies as the crawler stores them to WebSocket clients. The initial filter
+// comes from the `genre`, `quality`, `language` and `minimum_rating` parameters,
+// clients replace it by sending a MovieFeedSubscribeMessage.
+func h_MovieFeed(response Response, request Request) {
+	filter, err := getQueryMovieFilter(request)
+
+	if err != nil {
+		writeJsonError(response, HTTP.StatusBadRequest, err.Error())
+		return
+	}
+
+	var subscription *Events.Subscription = Events.Subscribe(map[string]bool{
+		Events.EVENT_TYPE_MOVIE_ADDED:   true,
+		Events.EVENT_TYPE_MOVIE_UPDATED: true,
+	}, 0)
+
+	if subscription == nil {
+		writeJsonError(response, HTTP.StatusServiceUnavailable, "Events are not available")
+		return
+	}
+
+	defer Events.Unsubscribe(subscription)
+
+	connection, err := websocketUpgrader.Upgrade(response, request, nil)
+
+	if err != nil {
+		Logger.WARN_CONTEXT(request.Context(), "Couldn't upgrade movie feed connection.", "error", err)
+		return
+	}
+
+	defer connection.Close()
+
+	var filters chan *Catalog.MovieFilter = make(chan *Catalog.MovieFilter)
+	var readerDone chan bool = make(chan bool)
+	var readerStop chan bool = make(chan bool)
+
+	defer close(readerStop)
+
+	go readMovieFeedFilters(connection, filters, readerDone, readerStop)
+
+	var subscribed *MovieFeedMessage = newMovieFeedMessage(WEBSOCKET_MESSAGE_TYPE_SUBSCRIBED)
+
+	subscribed.Filter = filter
+
+	if writeWebSocketJson(connection, subscribed) != nil {
+		return
+	}
+
+	var ping *time.Ticker = time.NewTicker(WEBSOCKET_PING_INTERVAL)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-readerDone:
+			return
+		case <-Context.Done():
+			connection.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(WEBSOCKET_WRITE_TIMEOUT))
+			return
+		case newFilter := <-filters:
+			var message *MovieFeedMessage = nil
+
+			if newFilter == nil {
+				message = newMovieFeedMessage(WEBSOCKET_MESSAGE_TYPE_ERROR)
+				message.Message = "Invalid subscribe message"
+			} else {
+				filter = newFilter
+
+				message = newMovieFeedMessage(WEBSOCKET_MESSAGE_TYPE_SUBSCRIBED)
+				message.Filter = filter
+			}
+
+			if writeWebSocketJson(connection, message) != nil {
+				return
+			}
+		case <-ping.C:
+			connection.SetWriteDeadline(time.Now().Add(WEBSOCKET_WRITE_TIMEOUT))
+
+			if connection.WriteMessage(websocket.PingMessage, nil) != nil {
+				return
+			}
+		case event, ok := <-subscription.Channel:
+			if !ok {
+				connection.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(WEBSOCKET_WRITE_TIMEOUT))
+				return
+			}
+
+			slug, _ := event.Data["Slug"].(string)
+
+			var details *Movie.MovieDetails = Catalog.GetMovieBySlug(slug)
+
+			if details == nil || !filter.Matches(details) {
+				continue
+			}
+
+			var message *MovieFeedMessage = newMovieFeedMessage(event.Type)
+
+			message.Movie = details
+
+			if writeWebSocketJson(connection, message) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,643 @@
+package HttpServer
+
+import (
+	"GServer/Auth"
+	"GServer/Events"
+	"GServer/Movie"
+	"GServer/Webhooks"
+	"fmt"
+	HTTP "net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OPENAPI_VERSION     = "3.0.3"
+	OPENAPI_API_VERSION = "1.0.0"
+
+	API_PARAMETER_IN_QUERY  = "query"
+	API_PARAMETER_IN_HEADER = "header"
+
+	API_PARAMETER_TYPE_STRING  = "string"
+	API_PARAMETER_TYPE_INTEGER = "integer"
+	API_PARAMETER_TYPE_NUMBER  = "number"
+
+	API_ERROR_CODE_BAD_REQUEST        = "bad_request"
+	API_ERROR_CODE_MISSING_PARAMETER  = "missing_parameter"
+	API_ERROR_CODE_INVALID_PARAMETER  = "invalid_parameter"
+	API_ERROR_CODE_NOT_FOUND          = "not_found"
+	API_ERROR_CODE_METHOD_NOT_ALLOWED = "method_not_allowed"
+	API_ERROR_CODE_INTERNAL_ERROR     = "internal_error"
+	API_ERROR_CODE_UNAVAILABLE        = "service_unavailable"
+	API_ERROR_CODE_UNAUTHORIZED       = "unauthorized"
+	API_ERROR_CODE_FORBIDDEN          = "forbidden"
+	API_ERROR_CODE_QUOTA_EXCEEDED     = "quota_exceeded"
+)
+
+type ApiParameter struct {
+	Name        string
+	In          string
+	Type        string
+	Description string
+
+	Required bool
+
+	Minimum *float64
+	Maximum *float64
+
+	Enum []string
+}
+
+type ApiEndpoint struct {
+	Path    string
+	Method  string
+	Summary string
+	Tags    []string
+
+	// Scope the API key must hold, public when empty.
+	Scope string
+
+	Parameters []*ApiParameter
+
+	// Content types of the successful response, JSON when empty.
+	ResponseTypes []string
+
+	// Endpoints speaking another protocol's error format validate their own parameters.
+	SkipValidation bool
+
+	// Writes errors raised before the handler runs, JSON error bodies when nil.
+	WriteError func(Response, int, *ApiError)
+
+	Handler HTTP.HandlerFunc
+}
+
+type ApiError struct {
+	Code    string
+	Message string
+	Field   string
+}
+
+var ApiEndpoints []*ApiEndpoint = nil
+
+func bound(value float64) *float64 {
+	return &value
+}
+
+func queryParameter(name string, parameterType string, description string) *ApiParameter {
+	var parameter *ApiParameter = new(ApiParameter)
+
+	parameter.Name = name
+	parameter.In = API_PARAMETER_IN_QUERY
+	parameter.Type = parameterType
+	parameter.Description = description
+
+	parameter.Required = false
+
+	parameter.Minimum = nil
+	parameter.Maximum = nil
+
+	parameter.Enum = nil
+
+	return parameter
+}
+
+func (this *ApiParameter) Require() *ApiParameter {
+	this.Required = true
+
+	return this
+}
+
+func (this *ApiParameter) Range(minimum *float64, maximum *float64) *ApiParameter {
+	this.Minimum = minimum
+	this.Maximum = maximum
+
+	return this
+}
+
+func (this *ApiParameter) OneOf(values ...string) *ApiParameter {
+	this.Enum = values
+
+	return this
+}
+
+func getPaginationParameters(maximumLimit float64) []*ApiParameter {
+	return []*ApiParameter{
+		queryParameter("page", API_PARAMETER_TYPE_INTEGER, "Page number, starting at 1.").Range(bound(1), bound(MAXIMUM_PAGE_NUMBER)),
+		queryParameter("limit", API_PARAMETER_TYPE_INTEGER, "Number of results per page.").Range(bound(1), bound(maximumLimit)),
+	}
+}
+
+func getMovieFilterParameters() []*ApiParameter {
+	return []*ApiParameter{
+		queryParameter("genre", API_PARAMETER_TYPE_STRING, "Only movies of this genre."),
+		queryParameter("quality", API_PARAMETER_TYPE_STRING, "Only movies with a torrent of this quality, like 1080p."),
+		queryParameter("language", API_PARAMETER_TYPE_STRING, "Only movies in this language."),
+		queryParameter("mpa_rating", API_PARAMETER_TYPE_STRING, "Only movies with this MPA rating."),
+		queryParameter("source", API_PARAMETER_TYPE_STRING, "Only movies from this source.").OneOf(Movie.MOVIE_SOURCE_YTS, Movie.MOVIE_SOURCE_INTERNET_ARCHIVE),
+		queryParameter("minimum_rating", API_PARAMETER_TYPE_NUMBER, "Only movies rated at least this.").Range(bound(0), bound(10)),
+		queryParameter("year_from", API_PARAMETER_TYPE_INTEGER, "Only movies released in or after this year.").Range(bound(0), nil),
+		queryParameter("year_to", API_PARAMETER_TYPE_INTEGER, "Only movies released in or before this year.").Range(bound(0), nil),
+		queryParameter("decade", API_PARAMETER_TYPE_INTEGER, "Only movies of the decade starting at this year, like 1990.").Range(bound(0), nil),
+	}
+}
+
+func joinParameters(groups ...[]*ApiParameter) []*ApiParameter {
+	var parameters []*ApiParameter = []*ApiParameter{}
+
+	for _, group := range groups {
+		parameters = append(parameters, group...)
+	}
+
+	return parameters
+}
+
+func newApiEndpoints() []*ApiEndpoint {
+	return []*ApiEndpoint{
+		{
+			Path: "/api/movies", Method: HTTP.MethodGet, Tags: []string{"Movies"},
+			Scope:      Auth.SCOPE_CATALOG_READ,
+			Summary:    "Lists stored movies with facet counts for the current filters.",
+			Parameters: joinParameters(getPaginationParameters(MAXIMUM_MOVIES_LIST_LIMIT), getMovieFilterParameters()),
+			Handler:    h_Movies,
+		},
+		{
+			Path: "/api/movie", Method: HTTP.MethodGet, Tags: []string{"Movies"},
+			Scope:   Auth.SCOPE_CATALOG_READ,
+			Summary: "Returns a stored movie.",
+			Parameters: []*ApiParameter{
+				queryParameter("slug", API_PARAMETER_TYPE_STRING, "Slug of the movie.").Require(),
+			},
+			Handler: h_Movie,
+		},
+		{
+			Path: "/api/search", Method: HTTP.MethodGet, Tags: []string{"Movies"},
+			Scope:   Auth.SCOPE_CATALOG_READ,
+			Summary: "Full-text search over titles, descriptions, genres and cast.",
+			Parameters: joinParameters([]*ApiParameter{
+				queryParameter("q", API_PARAMETER_TYPE_STRING, "Search query.").Require(),
+			}, getPaginationParameters(MAXIMUM_MOVIES_LIST_LIMIT), getMovieFilterParameters()),
+			Handler: h_Search,
+		},
+		{
+			Path: "/api/torrent", Method: HTTP.MethodGet, Tags: []string{"Torrents"},
+			Scope:   Auth.SCOPE_CATALOG_READ,
+			Summary: "Returns a torrent and the movies referencing it.",
+			Parameters: []*ApiParameter{
+				queryParameter("hash", API_PARAMETER_TYPE_STRING, "Info-hash of the torrent.").Require(),
+			},
+			Handler: h_Torrent,
+		},
+		{
+			Path: "/api/torrents/conflicts", Method: HTTP.MethodGet, Tags: []string{"Torrents"},
+			Scope:   Auth.SCOPE_CATALOG_READ,
+			Summary: "Lists torrents referenced by movies with different titles.",
+			Handler: h_TorrentConflicts,
+		},
+		{
+			Path: "/api/events", Method: HTTP.MethodGet, Tags: []string{"Events"},
+			Scope:   Auth.SCOPE_CATALOG_READ,
+			Summary: "Streams crawler and catalog events as Server-Sent Events.",
+			Parameters: []*ApiParameter{
+				queryParameter("types", API_PARAMETER_TYPE_STRING, "Comma separated event types, 'crawler.*' selects a group. Known types: "+strings.Join(Events.EventTypes, ", ")+"."),
+				queryParameter("last_event_id", API_PARAMETER_TYPE_INTEGER, "Resume after this event.").Range(bound(0), nil),
+				{Name: "Last-Event-ID", In: API_PARAMETER_IN_HEADER, Type: API_PARAMETER_TYPE_INTEGER, Description: "Resume after this event, sent by reconnecting browsers.", Minimum: bound(0)},
+			},
+			ResponseTypes: []string{"text/event-stream"},
+			Handler:       h_Events,
+		},
+		{
+			Path: "/api/ws/movies", Method: HTTP.MethodGet, Tags: []string{"Events"},
+			Scope:   Auth.SCOPE_CATALOG_READ,
+			Summary: "WebSocket feed of stored movies, clients send filters to change their subscription.",
+			Parameters: []*ApiParameter{
+				queryParameter("genre", API_PARAMETER_TYPE_STRING, "Only movies of this genre."),
+				queryParameter("quality", API_PARAMETER_TYPE_STRING, "Only movies with a torrent of this quality."),
+				queryParameter("language", API_PARAMETER_TYPE_STRING, "Only movies in this language."),
+				queryParameter("minimum_rating", API_PARAMETER_TYPE_NUMBER, "Only movies rated at least this.").Range(bound(0), bound(10)),
+			},
+			Handler: h_MovieFeed,
+		},
+		{
+			Path: "/feeds/latest", Method: HTTP.MethodGet, Tags: []string{"Feeds"},
+			Scope:   Auth.SCOPE_CATALOG_READ,
+			Summary: "RSS 2.0 or Atom feed of the latest additions.",
+			Parameters: joinParameters([]*ApiParameter{
+				queryParameter("format", API_PARAMETER_TYPE_STRING, "Feed format, RSS unless the Accept header asks for Atom.").OneOf(FEED_FORMAT_RSS, FEED_FORMAT_ATOM),
+				queryParameter("enclosure", API_PARAMETER_TYPE_STRING, "Point enclosures to the .torrent file or the magnet link.").OneOf("torrent", "magnet"),
+				queryParameter("limit", API_PARAMETER_TYPE_INTEGER, "Number of items.").Range(bound(1), bound(MAXIMUM_FEED_LIMIT)),
+			}, getMovieFilterParameters()),
+			ResponseTypes: []string{"application/rss+xml", "application/atom+xml"},
+			Handler:       h_FeedLatest,
+		},
+		{
+			Path: "/torznab/api", Method: HTTP.MethodGet, Tags: []string{"Torznab"},
+			Scope:   Auth.SCOPE_CATALOG_READ,
+			Summary: "Torznab indexer API, errors are reported as Torznab error documents.",
+			Parameters: []*ApiParameter{
+				queryParameter("t", API_PARAMETER_TYPE_STRING, "Function.").Require().OneOf("caps", "search", "movie"),
+				queryParameter("q", API_PARAMETER_TYPE_STRING, "Search query."),
+				queryParameter("imdbid", API_PARAMETER_TYPE_STRING, "IMDb id, with or without the 'tt' prefix."),
+				queryParameter("cat", API_PARAMETER_TYPE_STRING, "Comma separated Torznab categories."),
+				queryParameter("offset", API_PARAMETER_TYPE_INTEGER, "Number of items to skip.").Range(bound(0), nil),
+				queryParameter("limit", API_PARAMETER_TYPE_INTEGER, "Number of items.").Range(bound(1), bound(TORZNAB_MAXIMUM_LIMIT)),
+			},
+			ResponseTypes:  []string{"application/xml"},
+			SkipValidation: true,
+			WriteError:     writeTorznabApiError,
+			Handler:        h_Torznab,
+		},
+		{
+			Path: "/api/admin/webhooks", Method: HTTP.MethodGet, Tags: []string{"Admin"},
+			Scope:   Auth.SCOPE_WEBHOOKS,
+			Summary: "Lists the configured webhook targets.",
+			Handler: h_AdminWebhooks,
+		},
+		{
+			Path: "/api/admin/webhooks/deliveries", Method: HTTP.MethodGet, Tags: []string{"Admin"},
+			Scope:   Auth.SCOPE_WEBHOOKS,
+			Summary: "Lists webhook deliveries, the most recent first.",
+			Parameters: []*ApiParameter{
+				queryParameter("status", API_PARAMETER_TYPE_STRING, "Only deliveries with this status.").OneOf(Webhooks.DELIVERY_STATUS_PENDING, Webhooks.DELIVERY_STATUS_SUCCEEDED, Webhooks.DELIVERY_STATUS_FAILED),
+				queryParameter("event", API_PARAMETER_TYPE_STRING, "Only deliveries of this event type."),
+				queryParameter("url", API_PARAMETER_TYPE_STRING, "Only deliveries to this target."),
+				queryParameter("limit", API_PARAMETER_TYPE_INTEGER, "Number of deliveries.").Range(bound(1), bound(MAXIMUM_WEBHOOK_DELIVERIES_LIMIT)),
+			},
+			Handler: h_AdminWebhookDeliveries,
+		},
+		{
+			Path: "/api/admin/webhooks/delivery", Method: HTTP.MethodGet, Tags: []string{"Admin"},
+			Scope:   Auth.SCOPE_WEBHOOKS,
+			Summary: "Returns a webhook delivery with its attempts.",
+			Parameters: []*ApiParameter{
+				queryParameter("id", API_PARAMETER_TYPE_STRING, "Id of the delivery.").Require(),
+			},
+			Handler: h_AdminWebhookDelivery,
+		},
+		{
+			Path: "/api/admin/keys", Method: HTTP.MethodGet, Tags: []string{"Admin"},
+			Scope:   Auth.SCOPE_ADMIN,
+			Summary: "Lists API keys, revoked ones included. Keys are stored hashed and never returned.",
+			Handler: h_AdminApiKeys,
+		},
+		{
+			Path: "/api/admin/keys/issue", Method: HTTP.MethodPost, Tags: []string{"Admin"},
+			Scope:   Auth.SCOPE_ADMIN,
+			Summary: "Issues an API key, the response is the only time the key is shown.",
+			Parameters: []*ApiParameter{
+				queryParameter("name", API_PARAMETER_TYPE_STRING, "Name telling the key apart.").Require(),
+				queryParameter("scopes", API_PARAMETER_TYPE_STRING, "Comma separated scopes: "+strings.Join(Auth.Scopes, ", ")+".").Require(),
+				queryParameter("quota_requests", API_PARAMETER_TYPE_INTEGER, "Requests allowed per quota period, -1 disables the quota.").Range(bound(Auth.UNLIMITED_QUOTA), nil),
+				queryParameter("quota_period", API_PARAMETER_TYPE_INTEGER, "Quota period in seconds.").Range(bound(1), nil),
+			},
+			Handler: h_AdminIssueApiKey,
+		},
+		{
+			Path: "/api/admin/keys/revoke", Method: HTTP.MethodPost, Tags: []string{"Admin"},
+			Scope:   Auth.SCOPE_ADMIN,
+			Summary: "Revokes an issued API key.",
+			Parameters: []*ApiParameter{
+				queryParameter("id", API_PARAMETER_TYPE_STRING, "Id of the key.").Require(),
+			},
+			Handler: h_AdminRevokeApiKey,
+		},
+		{
+			Path: "/healthz", Method: HTTP.MethodGet, Tags: []string{"Meta"},
+			Summary: "Liveness, reports the state of every subsystem without probing upstreams. Only admin keys see the checks when API keys are enabled.",
+			Handler: h_Healthz,
+		},
+		{
+			Path: "/readyz", Method: HTTP.MethodGet, Tags: []string{"Meta"},
+			Summary: "Readiness, answers 503 while the API can't serve requests. Only admin keys see the checks when API keys are enabled.",
+			Handler: h_Readyz,
+		},
+		{
+			Path: "/metrics", Method: HTTP.MethodGet, Tags: []string{"Meta"},
+			Summary:       "Prometheus metrics in the text exposition format.",
+			ResponseTypes: []string{"text/plain"},
+			Handler:       h_Metrics,
+		},
+		{
+			Path: "/openapi.json", Method: HTTP.MethodGet, Tags: []string{"Meta"},
+			Summary: "This document.",
+			Handler: h_OpenAPI,
+		},
+	}
+}
+
+func newApiError(code string, message string, field string) *ApiError {
+	var apiError *ApiError = new(ApiError)
+
+	apiError.Code = code
+	apiError.Message = message
+	apiError.Field = field
+
+	return apiError
+}
+
+func getApiErrorCode(status int) string {
+	switch status {
+	case HTTP.StatusBadRequest:
+		return API_ERROR_CODE_BAD_REQUEST
+	case HTTP.StatusNotFound:
+		return API_ERROR_CODE_NOT_FOUND
+	case HTTP.StatusMethodNotAllowed:
+		return API_ERROR_CODE_METHOD_NOT_ALLOWED
+	case HTTP.StatusUnauthorized:
+		return API_ERROR_CODE_UNAUTHORIZED
+	case HTTP.StatusForbidden:
+		return API_ERROR_CODE_FORBIDDEN
+	case HTTP.StatusTooManyRequests:
+		return API_ERROR_CODE_QUOTA_EXCEEDED
+	case HTTP.StatusServiceUnavailable:
+		return API_ERROR_CODE_UNAVAILABLE
+	}
+
+	return API_ERROR_CODE_INTERNAL_ERROR
+}
+
+// Error bodies keep the YTS style envelope and add the machine readable code and
+// the parameter at fault.
+func writeApiError(response Response, status int, apiError *ApiError) {
+	var body map[string]any = map[string]any{
+		"status":         "error",
+		"status_message": apiError.Message,
+		"code":           apiError.Code,
+		"message":        apiError.Message,
+	}
+
+	if len(apiError.Field) > 0 {
+		body["field"] = apiError.Field
+	}
+
+	writeJson(response, status, body)
+}
+
+func (this *ApiParameter) Validate(value string) *ApiError {
+	var number float64 = 0
+
+	switch this.Type {
+	case API_PARAMETER_TYPE_INTEGER:
+		integer, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return newApiError(API_ERROR_CODE_INVALID_PARAMETER, fmt.Sprintf("`%s` must be an integer", this.Name), this.Name)
+		}
+
+		number = float64(integer)
+	case API_PARAMETER_TYPE_NUMBER:
+		float, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return newApiError(API_ERROR_CODE_INVALID_PARAMETER, fmt.Sprintf("`%s` must be a number", this.Name), this.Name)
+		}
+
+		number = float
+	}
+
+	if this.Minimum != nil && number < *this.Minimum {
+		return newApiError(API_ERROR_CODE_INVALID_PARAMETER, fmt.Sprintf("`%s` must be at least %g", this.Name, *this.Minimum), this.Name)
+	}
+
+	if this.Maximum != nil && number > *this.Maximum {
+		return newApiError(API_ERROR_CODE_INVALID_PARAMETER, fmt.Sprintf("`%s` must be at most %g", this.Name, *this.Maximum), this.Name)
+	}
+
+	if len(this.Enum) > 0 && !slices.ContainsFunc(this.Enum, func(e string) bool { return strings.EqualFold(e, value) }) {
+		return newApiError(API_ERROR_CODE_INVALID_PARAMETER, fmt.Sprintf("`%s` must be one of: %s", this.Name, strings.Join(this.Enum, ", ")), this.Name)
+	}
+
+	return nil
+}
+
+func (this *ApiEndpoint) IsMethodAllowed(method string) bool {
+	return method == this.Method || (this.Method == HTTP.MethodGet && method == HTTP.MethodHead)
+}
+
+// Checks the request against the parameters of the endpoint. Parameters the
+// endpoint doesn't declare are ignored, feed readers add their own cache busters.
+func (this *ApiEndpoint) Validate(request Request) (int, *ApiError) {
+	if this.SkipValidation {
+		return HTTP.StatusOK, nil
+	}
+
+	for _, parameter := range this.Parameters {
+		var value string = ""
+
+		if parameter.In == API_PARAMETER_IN_HEADER {
+			value = request.Header.Get(parameter.Name)
+		} else {
+			value = request.URL.Query().Get(parameter.Name)
+		}
+
+		if len(value) < 1 {
+			if parameter.Required {
+				return HTTP.StatusBadRequest, newApiError(API_ERROR_CODE_MISSING_PARAMETER, fmt.Sprintf("Missing `%s` parameter", parameter.Name), parameter.Name)
+			}
+
+			continue
+		}
+
+		if apiError := parameter.Validate(value); apiError != nil {
+			return HTTP.StatusBadRequest, apiError
+		}
+	}
+
+	return HTTP.StatusOK, nil
+}
+
+func (this *ApiEndpoint) ServeHTTP(response Response, request Request) {
+	var started time.Time = time.Now()
+
+	var recorder *statusRecorder = newStatusRecorder(response)
+
+	request = withCorrelationId(recorder, request)
+
+	defer func() {
+		observeRequest(this, request, recorder.Status, started)
+	}()
+
+	var writeError func(Response, int, *ApiError) = this.WriteError
+
+	if writeError == nil {
+		writeError = writeApiError
+	}
+
+	if !this.IsMethodAllowed(request.Method) {
+		recorder.Header().Set("Allow", this.Method)
+
+		writeError(recorder, HTTP.StatusMethodNotAllowed, newApiError(API_ERROR_CODE_METHOD_NOT_ALLOWED, "Method "+request.Method+" is not allowed", ""))
+		return
+	}
+
+	if status, apiError := this.Authorize(recorder, request); apiError != nil {
+		writeError(recorder, status, apiError)
+		return
+	}
+
+	if status, apiError := this.Validate(request); apiError != nil {
+		writeError(recorder, status, apiError)
+		return
+	}
+
+	this.Handler(recorder, request)
+}
+
+func getOpenAPIParameter(parameter *ApiParameter) map[string]any {
+	var schema map[string]any = map[string]any{
+		"type": parameter.Type,
+	}
+
+	if parameter.Minimum != nil {
+		schema["minimum"] = *parameter.Minimum
+	}
+
+	if parameter.Maximum != nil {
+		schema["maximum"] = *parameter.Maximum
+	}
+
+	if len(parameter.Enum) > 0 {
+		schema["enum"] = parameter.Enum
+	}
+
+	return map[string]any{
+		"name":        parameter.Name,
+		"in":          parameter.In,
+		"description": parameter.Description,
+		"required":    parameter.Required,
+		"schema":      schema,
+	}
+}
+
+func getOpenAPIResponses(endpoint *ApiEndpoint) map[string]any {
+	var content map[string]any = map[string]any{}
+
+	if len(endpoint.ResponseTypes) < 1 {
+		content["application/json"] = map[string]any{
+			"schema": map[string]any{"$ref": "#/components/schemas/Response"},
+		}
+	}
+
+	for _, responseType := range endpoint.ResponseTypes {
+		content[responseType] = map[string]any{
+			"schema": map[string]any{"type": "string"},
+		}
+	}
+
+	var errorContent map[string]any = map[string]any{
+		"application/json": map[string]any{
+			"schema": map[string]any{"$ref": "#/components/schemas/Error"},
+		},
+	}
+
+	var responses map[string]any = map[string]any{
+		"200": map[string]any{"description": "Success", "content": content},
+		"400": map[string]any{"description": "Invalid parameters", "content": errorContent},
+		"404": map[string]any{"description": "Not found", "content": errorContent},
+		"405": map[string]any{"description": "Method not allowed", "content": errorContent},
+		"503": map[string]any{"description": "Service unavailable", "content": errorContent},
+	}
+
+	if len(endpoint.Scope) > 0 {
+		responses["401"] = map[string]any{"description": "Missing or invalid API key", "content": errorContent}
+		responses["403"] = map[string]any{"description": "API key lacks the scope", "content": errorContent}
+		responses["429"] = map[string]any{"description": "API key quota exceeded", "content": errorContent}
+	}
+
+	for _, value := range responses {
+		value.(map[string]any)["headers"] = map[string]any{
+			CORRELATION_ID_HEADER: map[string]any{"$ref": "#/components/headers/CorrelationId"},
+		}
+	}
+
+	return responses
+}
+
+func GetOpenAPIDocument(serverURL string) map[string]any {
+	var paths map[string]any = map[string]any{}
+
+	for _, endpoint := range ApiEndpoints {
+		var parameters []map[string]any = []map[string]any{}
+
+		for _, parameter := range endpoint.Parameters {
+			parameters = append(parameters, getOpenAPIParameter(parameter))
+		}
+
+		var operationId string = strings.ReplaceAll(strings.Trim(endpoint.Path, "/"), "/", "_")
+
+		var operation map[string]any = map[string]any{
+			"operationId": operationId,
+			"summary":     endpoint.Summary,
+			"tags":        endpoint.Tags,
+			"parameters":  parameters,
+			"responses":   getOpenAPIResponses(endpoint),
+			"security":    []map[string]any{},
+		}
+
+		if len(endpoint.Scope) > 0 {
+			operation["description"] = "Requires an API key with the `" + endpoint.Scope + "` scope."
+
+			operation["security"] = []map[string]any{
+				{"bearerAuth": []string{endpoint.Scope}},
+				{"headerApiKey": []string{endpoint.Scope}},
+				{"queryApiKey": []string{endpoint.Scope}},
+			}
+		}
+
+		paths[endpoint.Path] = map[string]any{
+			strings.ToLower(endpoint.Method): operation,
+		}
+	}
+
+	return map[string]any{
+		"openapi": OPENAPI_VERSION,
+		"info": map[string]any{
+			"title":       "GServer API",
+			"description": "Movie catalog crawled from YTS and the Internet Archive.",
+			"version":     OPENAPI_API_VERSION,
+		},
+		"servers": []map[string]any{
+			{"url": serverURL},
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				"bearerAuth":   map[string]any{"type": "http", "scheme": "bearer"},
+				"headerApiKey": map[string]any{"type": "apiKey", "in": "header", "name": API_KEY_HEADER},
+				"queryApiKey":  map[string]any{"type": "apiKey", "in": "query", "name": API_KEY_QUERY_PARAMETER},
+			},
+			"headers": map[string]any{
+				"CorrelationId": map[string]any{
+					"description": "Id of the request in the server logs, taken from the " + CORRELATION_ID_HEADER + " or " + REQUEST_ID_HEADER + " request header when valid.",
+					"schema":      map[string]any{"type": "string"},
+				},
+			},
+			"schemas": map[string]any{
+				"Response": map[string]any{
+					"type":     "object",
+					"required": []string{"status", "data"},
+					"properties": map[string]any{
+						"status": map[string]any{"type": "string", "enum": []string{"ok"}},
+						"data":   map[string]any{"type": "object"},
+					},
+				},
+				"Error": map[string]any{
+					"type":     "object",
+					"required": []string{"status", "code", "message"},
+					"properties": map[string]any{
+						"status":         map[string]any{"type": "string", "enum": []string{"error"}},
+						"status_message": map[string]any{"type": "string"},
+						"code":           map[string]any{"type": "string"},
+						"message":        map[string]any{"type": "string"},
+						"field":          map[string]any{"type": "string", "description": "Parameter at fault."},
+					},
+				},
+			},
+		},
+	}
+}
+
+func h_OpenAPI(response Response, request Request) {
+	writeJson(response, HTTP.StatusOK, GetOpenAPIDocument(getRequestBaseURL(request)))
+}
@@ -0,0 +1,124 @@
+package HttpServer
+
+import (
+	"GServer/Logger"
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// Serves the configured key pair to TLS handshakes and swaps it when the files
+// change on disk.
+type CertificateReloader struct {
+	CertificateFile string
+	KeyFile         string
+
+	certificate *tls.Certificate
+
+	certificateModTime time.Time
+	keyModTime         time.Time
+
+	mutex sync.RWMutex
+}
+
+func getFileModTime(filePath string) (time.Time, error) {
+	info, err := os.Stat(filePath)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return info.ModTime(), nil
+}
+
+func NewCertificateReloader(certificateFile string, keyFile string) (*CertificateReloader, error) {
+	var reloader *CertificateReloader = new(CertificateReloader)
+
+	reloader.CertificateFile = certificateFile
+	reloader.KeyFile = keyFile
+
+	reloader.certificate = nil
+
+	reloader.certificateModTime = time.Time{}
+	reloader.keyModTime = time.Time{}
+
+	if _, err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// Loads the key pair again if either file changed since the last load. A pair
+// that fails to load keeps the previous certificate in use.
+func (this *CertificateReloader) Reload() (bool, error) {
+	certificateModTime, err := getFileModTime(this.CertificateFile)
+
+	if err != nil {
+		return false, err
+	}
+
+	keyModTime, err := getFileModTime(this.KeyFile)
+
+	if err != nil {
+		return false, err
+	}
+
+	this.mutex.RLock()
+
+	var changed bool = this.certificate == nil || !certificateModTime.Equal(this.certificateModTime) || !keyModTime.Equal(this.keyModTime)
+
+	this.mutex.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(this.CertificateFile, this.KeyFile)
+
+	if err != nil {
+		return false, err
+	}
+
+	this.mutex.Lock()
+
+	this.certificate = &certificate
+
+	this.certificateModTime = certificateModTime
+	this.keyModTime = keyModTime
+
+	this.mutex.Unlock()
+
+	return true, nil
+}
+
+func (this *CertificateReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.certificate, nil
+}
+
+func (this *CertificateReloader) Watch(done <-chan struct{}, interval time.Duration) {
+	var ticker *time.Ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			reloaded, err := this.Reload()
+
+			if err != nil {
+				Logger.WARN("Couldn't reload TLS certificate, keeping the current one.", "certificate", this.CertificateFile, "error", err)
+				continue
+			}
+
+			if reloaded {
+				Logger.INFO("TLS certificate reloaded.", "certificate", this.CertificateFile)
+			}
+		}
+	}
+}
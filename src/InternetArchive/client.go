@@ -3,9 +3,12 @@ package InternetArchive
 import (
 	"GServer/Config"
 	"GServer/Defaults"
+	"GServer/Events"
 	"GServer/Logger"
+	"GServer/Metrics"
 	"GServer/Movie"
 	"GServer/TaskManager"
+	"GServer/Tracing"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -14,9 +17,12 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 	"unsafe"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type JsonDictionary map[string]any
@@ -34,14 +40,24 @@ type Client struct {
 	HttpClient *http.Client
 }
 
-func (this *Client) fetch(url *url.URL, method string, payload []byte) (JsonDictionary, error) {
+func (this *Client) fetch(ctx context.Context, url *url.URL, method string, payload []byte) (_ JsonDictionary, err error) {
+	ctx, span := Tracing.StartSpan(ctx, "upstream.fetch",
+		attribute.String(Tracing.ATTRIBUTE_SOURCE, Movie.MOVIE_SOURCE_INTERNET_ARCHIVE),
+		attribute.String("http.request.method", method),
+		attribute.String("url.full", url.String()),
+	)
+
+	defer func() {
+		Tracing.EndSpan(span, err)
+	}()
+
 	var buffer *bytes.Buffer = bytes.NewBufferString("")
 
 	if payload != nil {
 		buffer = bytes.NewBuffer(payload)
 	}
 
-	requestContext, requestContextClose := context.WithTimeout(this.Context, this.Timeout)
+	requestContext, requestContextClose := context.WithTimeout(ctx, this.Timeout)
 
 	defer requestContextClose()
 
@@ -51,12 +67,21 @@ func (this *Client) fetch(url *url.URL, method string, payload []byte) (JsonDict
 		return nil, err
 	}
 
+	var started time.Time = time.Now()
+
 	response, err := this.HttpClient.Do(request)
 
+	Metrics.UpstreamRequestDuration.Observe(time.Since(started).Seconds(), Movie.MOVIE_SOURCE_INTERNET_ARCHIVE)
+
 	if err != nil {
+		Metrics.UpstreamRequests.Inc(Movie.MOVIE_SOURCE_INTERNET_ARCHIVE, Metrics.UPSTREAM_STATUS_ERROR)
 		return nil, err
 	}
 
+	Metrics.UpstreamRequests.Inc(Movie.MOVIE_SOURCE_INTERNET_ARCHIVE, strconv.Itoa(response.StatusCode))
+
+	span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+
 	defer response.Body.Close()
 
 	bodyBytes, err := io.ReadAll(response.Body)
@@ -136,7 +161,22 @@ func setMovieDetail[T any](detail *T, jsonData *map[string]interface{}, field st
 	*detail = value
 }
 
-func parseMovieDetailsFromJsonData(details *Movie.MovieDetails, jsonData *map[string]interface{}, client *Client) {
+func parseMovieDetailsFromJsonData(ctx context.Context, details *Movie.MovieDetails, jsonData *map[string]interface{}, client *Client) {
+	ctx, span := Tracing.StartSpan(ctx, "movie.parse", attribute.String(Tracing.ATTRIBUTE_SOURCE, Movie.MOVIE_SOURCE_INTERNET_ARCHIVE))
+
+	defer func() {
+		span.SetAttributes(
+			attribute.String(Tracing.ATTRIBUTE_MOVIE_ID, details.SpecialIdentifier),
+			attribute.String(Tracing.ATTRIBUTE_MOVIE_TITLE, details.Title),
+			attribute.Int("gserver.movie.torrent_count", len(details.Torrents)),
+		)
+
+		Tracing.EndSpan(span, nil)
+	}()
+
+	details.Source = Movie.MOVIE_SOURCE_INTERNET_ARCHIVE
+	details.Sources = []string{Movie.MOVIE_SOURCE_INTERNET_ARCHIVE}
+
 	setMovieDetail(&details.SpecialIdentifier, jsonData, "identifier")
 
 	setMovieDetail(&details.Size, jsonData, "item_size")
@@ -149,25 +189,64 @@ func parseMovieDetailsFromJsonData(details *Movie.MovieDetails, jsonData *map[st
 
 	setMovieDetail(&details.DateUploaded, jsonData, "date")
 
+	Movie.NormalizeMovieDetailsTitle(details)
+
 	if len(details.SpecialIdentifier) < 1 {
 		return
 	}
 
 	var torrent *Movie.MovieTorrentInfo = Movie.NewMovieTorrentInfo()
 
+	torrent.Source = Movie.MOVIE_SOURCE_INTERNET_ARCHIVE
+
 	torrent.URL = fmt.Sprintf(client.TorrentURLFormat, details.SpecialIdentifier, details.SpecialIdentifier)
 
-	err := Movie.ParseTorrentFromUrl(client.Context, torrent.URL, torrent)
+	tmContext, tmContextCancel := context.WithTimeout(ctx, client.Timeout)
 
-	if err != nil {
-		Logger.WARN("Failed to parse torrent file. [URL: " + torrent.URL + ", Message: " + err.Error() + "]")
-		return
-	}
+	defer tmContextCancel()
+
+	var taskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(tmContext, "IA_TORRENT_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(details))), Config.Get().TasksMaxThreads.IA_TORRENT_PARSER)
+
+	taskManager.Start()
+
+	taskManager.AddTaskWithDelay(func(t *TaskManager.Task) {
+		err := Movie.ParseTorrentFromUrl(t.Context, torrent.URL, torrent)
+
+		if err != nil {
+			Logger.WARN_CONTEXT(t.Context, "Failed to parse torrent file.", "url", torrent.URL, "title", details.Title, "error", err)
+
+			Events.Publish(Events.EVENT_TYPE_TORRENT_PARSE_FAILED, Movie.MOVIE_SOURCE_INTERNET_ARCHIVE, Events.EventData{
+				"Title": details.Title,
+				"URL":   torrent.URL,
+				"Error": err.Error(),
+			})
+
+			Metrics.TorrentsParseFailures.Inc(Movie.MOVIE_SOURCE_INTERNET_ARCHIVE)
 
-	details.Torrents = append(details.Torrents, torrent)
+			// The torrent stays listed by its URL, named after the item the torrent file is named after.
+			if len(torrent.Name) < 1 {
+				torrent.Name = details.SpecialIdentifier
+			}
+		} else {
+			Metrics.TorrentsParsed.Inc(Movie.MOVIE_SOURCE_INTERNET_ARCHIVE)
+		}
+
+		// Names are parsed for the fields still empty.
+		Movie.FillMissingTorrentReleaseFields(torrent)
+
+		details.Torrents = append(details.Torrents, torrent)
+	}, Config.Get().TasksExecutionDelay.IA_TORRENT_PARSER)
+
+	taskManager.WaitForTasks()
+
+	TaskManager.DeleteTaskManager(taskManager.Name)
 }
 
 func (this *Client) Search(params *SearchParameters) ([]*Movie.MovieDetails, error, float64, float64) {
+	return this.SearchWithContext(this.Context, params)
+}
+
+func (this *Client) SearchWithContext(ctx context.Context, params *SearchParameters) ([]*Movie.MovieDetails, error, float64, float64) {
 	url, err := url.Parse(this.AdvancedSearchEndpoint)
 
 	var queryParams *SearchParameters = NewSearchParameters("")
@@ -182,7 +261,7 @@ func (this *Client) Search(params *SearchParameters) ([]*Movie.MovieDetails, err
 		return nil, err, 0, 0
 	}
 
-	responseJsonData, err := this.fetch(url, http.MethodGet, nil)
+	responseJsonData, err := this.fetch(ctx, url, http.MethodGet, nil)
 
 	if err != nil {
 		return nil, err, 0, 0
@@ -219,11 +298,11 @@ func (this *Client) Search(params *SearchParameters) ([]*Movie.MovieDetails, err
 
 	var appendListMutex sync.Mutex = sync.Mutex{}
 
-	tmContext, tmContextCancel := context.WithTimeout(this.Context, this.Timeout)
+	tmContext, tmContextCancel := context.WithTimeout(ctx, this.Timeout)
 
 	defer tmContextCancel()
 
-	var taskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(tmContext, "IA_MOVIE_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(&moviesListResult))), Config.Main.TasksMaxThreads.IA_MOVIE_PARSER)
+	var taskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(tmContext, "IA_MOVIE_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(&moviesListResult))), Config.Get().TasksMaxThreads.IA_MOVIE_PARSER)
 
 	taskManager.Start()
 
@@ -231,18 +310,21 @@ func (this *Client) Search(params *SearchParameters) ([]*Movie.MovieDetails, err
 		item, ok := value.(map[string]interface{})
 
 		if !ok {
+			Metrics.MoviesParseFailures.Inc(Movie.MOVIE_SOURCE_INTERNET_ARCHIVE)
 			continue
 		}
 
 		var details *Movie.MovieDetails = Movie.NewMovieDetails()
 
 		taskManager.AddTaskWithDelay(func(t *TaskManager.Task) {
-			parseMovieDetailsFromJsonData(details, &item, this)
+			parseMovieDetailsFromJsonData(t.Context, details, &item, this)
+
+			Metrics.MoviesParsed.Inc(Movie.MOVIE_SOURCE_INTERNET_ARCHIVE)
 
 			appendListMutex.Lock()
 			moviesListResult = append(moviesListResult, details)
 			appendListMutex.Unlock()
-		}, 0)
+		}, Config.Get().TasksExecutionDelay.IA_MOVIE_PARSER)
 	}
 
 	taskManager.WaitForTasks()
@@ -253,16 +335,24 @@ func (this *Client) Search(params *SearchParameters) ([]*Movie.MovieDetails, err
 }
 
 func (this *Client) GetMovieList(params *SearchParameters, extra string) ([]*Movie.MovieDetails, error, float64, float64) {
+	return this.GetMovieListWithContext(this.Context, params, extra)
+}
+
+func (this *Client) GetMovieListWithContext(ctx context.Context, params *SearchParameters, extra string) ([]*Movie.MovieDetails, error, float64, float64) {
 	params.Query = "mediatype:(movies) AND (subject:\"movie\" OR subject:\"serial\" OR subject:\"animation\" OR subject:\"cartoon\" OR subject:\"anime\")"
 
 	if len(extra) > 0 {
 		params.Query += " " + extra
 	}
 
-	return this.Search(params)
+	return this.SearchWithContext(ctx, params)
 }
 
 func (this *Client) GetMovieCount(params *SearchParameters, extra string) (float64, error) {
+	return this.GetMovieCountWithContext(this.Context, params, extra)
+}
+
+func (this *Client) GetMovieCountWithContext(ctx context.Context, params *SearchParameters, extra string) (float64, error) {
 	params.Query = "mediatype:(movies) AND (subject:\"movie\" OR subject:\"serial\" OR subject:\"animation\" OR subject:\"cartoon\" OR subject:\"anime\")"
 
 	if len(extra) > 0 {
@@ -283,7 +373,7 @@ func (this *Client) GetMovieCount(params *SearchParameters, extra string) (float
 		return 0, err
 	}
 
-	responseJsonData, err := this.fetch(url, http.MethodGet, nil)
+	responseJsonData, err := this.fetch(ctx, url, http.MethodGet, nil)
 
 	if err != nil {
 		return 0, err
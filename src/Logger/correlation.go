@@ -0,0 +1,58 @@
+package Logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+const (
+	// Attribute carrying the correlation id of the HTTP request or crawl page a record belongs to.
+	CORRELATION_ID_KEY = "correlation_id"
+
+	CORRELATION_ID_MAXIMUM_LENGTH = 128
+)
+
+type correlationIdContextKey struct{}
+
+func NewCorrelationId() string {
+	var data []byte = make([]byte, 8)
+
+	if _, err := rand.Read(data); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(data)
+}
+
+// Ids coming from clients end up in logs and headers, so only short printable ones are kept.
+func IsValidCorrelationId(id string) bool {
+	if len(id) < 1 || len(id) > CORRELATION_ID_MAXIMUM_LENGTH {
+		return false
+	}
+
+	for _, character := range id {
+		if character < '!' || character > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func WithCorrelationId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIdContextKey{}, id)
+}
+
+// Returns an empty string when `ctx` carries no correlation id.
+func GetCorrelationId(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, _ := ctx.Value(correlationIdContextKey{}).(string)
+
+	return id
+}
@@ -1,81 +1,223 @@
 package Logger
 
 import (
-	"bytes"
-	"fmt"
+	"context"
+	"errors"
 	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type ENUM_LOG_LEVEL int32
+const (
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+
+	// Attribute naming the package a record was logged from.
+	PACKAGE_KEY = "package"
+)
+
+type ENUM_LOG_LEVEL = slog.Level
 
 const (
-	LOG_LEVEL_INFO ENUM_LOG_LEVEL = iota
-	LOG_LEVEL_WARNING
-	LOG_LEVEL_ERROR
-	LOG_LEVEL_DEBUG
+	LOG_LEVEL_DEBUG   ENUM_LOG_LEVEL = slog.LevelDebug
+	LOG_LEVEL_INFO    ENUM_LOG_LEVEL = slog.LevelInfo
+	LOG_LEVEL_WARNING ENUM_LOG_LEVEL = slog.LevelWarn
+	LOG_LEVEL_ERROR   ENUM_LOG_LEVEL = slog.LevelError
 )
 
-func (this ENUM_LOG_LEVEL) String() string {
-	return []string{
-		"INFO",
-		"WARNING",
-		"ERROR",
-		"DEBUG",
-	}[this]
+type levelSettings struct {
+	Level ENUM_LOG_LEVEL
+
+	// Lower case package name to the minimum level of records logged from it.
+	PackageLevels map[string]ENUM_LOG_LEVEL
+}
+
+var handler atomic.Pointer[slog.Handler]
+
+// Held for reading while a record is handled, so SetSinks can wait for writes
+// through the handler it replaced.
+var handlerMutex sync.RWMutex
+var levels atomic.Pointer[levelSettings]
+
+// Function entry to package name, resolved once per call site.
+var packageNames sync.Map
+
+func init() {
+	SetHandler(NewHandler(LOG_FORMAT_TEXT, LOG_LEVEL_DEBUG, os.Stdout))
+
+	levels.Store(&levelSettings{Level: LOG_LEVEL_INFO, PackageLevels: map[string]ENUM_LOG_LEVEL{}})
+}
+
+func ParseLevel(value string) (ENUM_LOG_LEVEL, error) {
+	var level ENUM_LOG_LEVEL = LOG_LEVEL_INFO
+
+	if strings.EqualFold(value, "warning") {
+		value = "warn"
+	}
+
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return LOG_LEVEL_INFO, errors.New("Unknown log level '" + value + "'")
+	}
+
+	return level, nil
 }
 
-func generateLogString(level ENUM_LOG_LEVEL, args ...any) string {
-	var stream *bytes.Buffer = &bytes.Buffer{}
+// Records below the package minimum are dropped before they reach handlers,
+// `level` only filters further for this handler.
+func NewHandler(format string, level ENUM_LOG_LEVEL, writer io.Writer) slog.Handler {
+	var options *slog.HandlerOptions = &slog.HandlerOptions{Level: level}
+
+	if strings.EqualFold(format, LOG_FORMAT_JSON) {
+		return slog.NewJSONHandler(writer, options)
+	}
 
-	stream.WriteString(time.Now().Format("[2006-01-02 15:04:05] "))
-	stream.WriteString(level.String() + ": ")
+	return slog.NewTextHandler(writer, options)
+}
 
-	fmt.Fprint(stream, args...)
+func SetHandler(value slog.Handler) {
+	handler.Store(&value)
+}
 
-	data, err := io.ReadAll(stream)
+func GetHandler() slog.Handler {
+	return *handler.Load()
+}
+
+// Sets the minimum levels, `packageLevels` maps package names like "Crawler"
+// to a level overriding `level` for records logged from that package.
+func Configure(level string, packageLevels map[string]string) error {
+	var settings *levelSettings = new(levelSettings)
+
+	var errs []error = []error{}
+
+	parsed, err := ParseLevel(level)
 
 	if err != nil {
-		return ""
+		errs = append(errs, err)
+	}
+
+	settings.Level = parsed
+	settings.PackageLevels = map[string]ENUM_LOG_LEVEL{}
+
+	for name, value := range packageLevels {
+		parsed, err := ParseLevel(value)
+
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		settings.PackageLevels[strings.ToLower(name)] = parsed
+	}
+
+	levels.Store(settings)
+
+	return errors.Join(errs...)
+}
+
+// "GServer/Crawler.(*Client).crawl" belongs to "Crawler", "main.main" to "main".
+func getPackageName(pc uintptr) string {
+	if name, exists := packageNames.Load(pc); exists {
+		return name.(string)
+	}
+
+	var name string = "unknown"
+
+	// Frames resolve calls inlined into the caller, FuncForPC would name the innermost function.
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	if len(frame.Function) > 0 {
+		name = frame.Function
+
+		if index := strings.LastIndex(name, "/"); index >= 0 {
+			name = name[index+1:]
+		}
+
+		if index := strings.Index(name, "."); index >= 0 {
+			name = name[:index]
+		}
+	}
+
+	packageNames.Store(pc, name)
+
+	return name
+}
+
+func IsEnabled(packageName string, level ENUM_LOG_LEVEL) bool {
+	var settings *levelSettings = levels.Load()
+
+	if minimum, exists := settings.PackageLevels[strings.ToLower(packageName)]; exists {
+		return level >= minimum
 	}
 
-	return string(data)
+	return level >= settings.Level
+}
+
+// `fields` are slog key/value pairs or slog.Attr values.
+func Log(ctx context.Context, level ENUM_LOG_LEVEL, message string, fields ...any) {
+	logRecord(ctx, 2, level, message, fields...)
 }
 
-func Log(level ENUM_LOG_LEVEL, args ...any) {
-	var output string = generateLogString(level, args...)
+func logRecord(ctx context.Context, skip int, level ENUM_LOG_LEVEL, message string, fields ...any) {
+	var pcs [1]uintptr
+
+	runtime.Callers(skip+1, pcs[:])
 
-	fmt.Println(output)
+	var packageName string = getPackageName(pcs[0])
+
+	if !IsEnabled(packageName, level) {
+		return
+	}
+
+	var record slog.Record = slog.NewRecord(time.Now(), level, message, pcs[0])
+
+	record.AddAttrs(slog.String(PACKAGE_KEY, packageName))
+
+	if id := GetCorrelationId(ctx); len(id) > 0 {
+		record.AddAttrs(slog.String(CORRELATION_ID_KEY, id))
+	}
+	record.Add(fields...)
+
+	handlerMutex.RLock()
+	defer handlerMutex.RUnlock()
+
+	GetHandler().Handle(ctx, record)
 }
 
-func F_INFO(args ...any) string {
-	return generateLogString(LOG_LEVEL_INFO, args...)
+func INFO(message string, fields ...any) {
+	logRecord(context.Background(), 2, LOG_LEVEL_INFO, message, fields...)
 }
 
-func F_WARN(args ...any) string {
-	return generateLogString(LOG_LEVEL_WARNING, args...)
+func WARN(message string, fields ...any) {
+	logRecord(context.Background(), 2, LOG_LEVEL_WARNING, message, fields...)
 }
 
-func F_ERROR(args ...any) string {
-	return generateLogString(LOG_LEVEL_ERROR, args...)
+func ERROR(message string, fields ...any) {
+	logRecord(context.Background(), 2, LOG_LEVEL_ERROR, message, fields...)
 }
 
-func F_DEBUG(args ...any) string {
-	return generateLogString(LOG_LEVEL_DEBUG, args...)
+func DEBUG(message string, fields ...any) {
+	logRecord(context.Background(), 2, LOG_LEVEL_DEBUG, message, fields...)
 }
 
-func INFO(args ...any) {
-	Log(LOG_LEVEL_INFO, args...)
+// The *_CONTEXT variants add the correlation id carried by `ctx` to the record.
+
+func INFO_CONTEXT(ctx context.Context, message string, fields ...any) {
+	logRecord(ctx, 2, LOG_LEVEL_INFO, message, fields...)
 }
 
-func WARN(args ...any) {
-	Log(LOG_LEVEL_WARNING, args...)
+func WARN_CONTEXT(ctx context.Context, message string, fields ...any) {
+	logRecord(ctx, 2, LOG_LEVEL_WARNING, message, fields...)
 }
 
-func ERROR(args ...any) {
-	Log(LOG_LEVEL_ERROR, args...)
+func ERROR_CONTEXT(ctx context.Context, message string, fields ...any) {
+	logRecord(ctx, 2, LOG_LEVEL_ERROR, message, fields...)
 }
 
-func DEBUG(args ...any) {
-	Log(LOG_LEVEL_DEBUG, args...)
+func DEBUG_CONTEXT(ctx context.Context, message string, fields ...any) {
+	logRecord(ctx, 2, LOG_LEVEL_DEBUG, message, fields...)
 }
@@ -0,0 +1,318 @@
+package Logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Suffix of rotated files, sorts in rotation order.
+	ROTATED_FILE_TIME_FORMAT = "2006-01-02T15-04-05.000"
+
+	COMPRESSED_FILE_EXTENSION = ".gz"
+)
+
+// Log file rotated once it grows past `MaxSize` bytes or a `RotationInterval`
+// boundary passes. Rotated files are renamed to "<name>-<time><ext>", optionally
+// gzipped, and removed past `MaxBackups` files or `MaxBackupAge`. Zero or negative
+// limits turn the matching rule off.
+type RotatingFile struct {
+	Path string
+
+	MaxSize          int64
+	RotationInterval time.Duration
+
+	MaxBackups   int
+	MaxBackupAge time.Duration
+
+	Compress bool
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+
+	mutex sync.Mutex
+
+	// Compression and cleanup of rotated files run in the background.
+	maintenance sync.WaitGroup
+}
+
+func NewRotatingFile(path string, maxSize int64, rotationInterval time.Duration, maxBackups int, maxBackupAge time.Duration, compress bool) *RotatingFile {
+	var file *RotatingFile = new(RotatingFile)
+
+	file.Path = path
+
+	file.MaxSize = maxSize
+	file.RotationInterval = rotationInterval
+
+	file.MaxBackups = maxBackups
+	file.MaxBackupAge = maxBackupAge
+
+	file.Compress = compress
+
+	file.file = nil
+	file.size = 0
+	file.openedAt = time.Time{}
+	file.closed = false
+
+	return file
+}
+
+// Must be called with the mutex held.
+func (this *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(this.Path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(this.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	this.file = file
+	this.size = info.Size()
+	this.openedAt = time.Now()
+
+	// An existing file keeps its rotation period across restarts.
+	if this.size > 0 {
+		this.openedAt = info.ModTime()
+	}
+
+	return nil
+}
+
+// Must be called with the mutex held.
+func (this *RotatingFile) shouldRotate(now time.Time, writeSize int) bool {
+	if this.size < 1 {
+		return false
+	}
+
+	if this.MaxSize > 0 && this.size+int64(writeSize) > this.MaxSize {
+		return true
+	}
+
+	if this.RotationInterval > 0 && !now.Truncate(this.RotationInterval).Equal(this.openedAt.Truncate(this.RotationInterval)) {
+		return true
+	}
+
+	return false
+}
+
+func (this *RotatingFile) getRotatedFilePrefix() (string, string) {
+	var extension string = filepath.Ext(this.Path)
+
+	return strings.TrimSuffix(this.Path, extension) + "-", extension
+}
+
+// Must be called with the mutex held.
+func (this *RotatingFile) rotate(now time.Time) error {
+	if this.file != nil {
+		this.file.Close()
+
+		this.file = nil
+	}
+
+	prefix, extension := this.getRotatedFilePrefix()
+
+	var rotatedPath string = prefix + now.Format(ROTATED_FILE_TIME_FORMAT) + extension
+
+	if err := os.Rename(this.Path, rotatedPath); err != nil && !os.IsNotExist(err) {
+		// Keep logging to the current file rather than dropping records.
+		if err := this.open(); err != nil {
+			return err
+		}
+
+		return err
+	}
+
+	this.maintenance.Add(1)
+
+	go func() {
+		defer this.maintenance.Done()
+
+		if this.Compress {
+			compressFile(rotatedPath)
+		}
+
+		this.removeOldBackups()
+	}()
+
+	return this.open()
+}
+
+func compressFile(path string) error {
+	source, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer source.Close()
+
+	destination, err := os.OpenFile(path+COMPRESSED_FILE_EXTENSION, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	var writer *gzip.Writer = gzip.NewWriter(destination)
+
+	if _, err := io.Copy(writer, source); err != nil {
+		writer.Close()
+		destination.Close()
+
+		os.Remove(path + COMPRESSED_FILE_EXTENSION)
+
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		destination.Close()
+
+		os.Remove(path + COMPRESSED_FILE_EXTENSION)
+
+		return err
+	}
+
+	if err := destination.Close(); err != nil {
+		return err
+	}
+
+	source.Close()
+
+	return os.Remove(path)
+}
+
+type rotatedFile struct {
+	Path      string
+	RotatedAt time.Time
+}
+
+// Returns rotated files of this log, newest first.
+func (this *RotatingFile) getRotatedFiles() []rotatedFile {
+	prefix, extension := this.getRotatedFilePrefix()
+
+	matches, err := filepath.Glob(prefix + "*")
+
+	if err != nil {
+		return []rotatedFile{}
+	}
+
+	var files []rotatedFile = []rotatedFile{}
+
+	for _, match := range matches {
+		// Files still being compressed are counted once, by their compressed copy.
+		if slices.Contains(matches, match+COMPRESSED_FILE_EXTENSION) {
+			continue
+		}
+
+		var stamp string = strings.TrimPrefix(match, prefix)
+
+		stamp = strings.TrimSuffix(stamp, COMPRESSED_FILE_EXTENSION)
+		stamp = strings.TrimSuffix(stamp, extension)
+
+		rotatedAt, err := time.ParseInLocation(ROTATED_FILE_TIME_FORMAT, stamp, time.Local)
+
+		if err != nil {
+			continue
+		}
+
+		files = append(files, rotatedFile{Path: match, RotatedAt: rotatedAt})
+	}
+
+	sort.Slice(files, func(i int, j int) bool {
+		return files[i].RotatedAt.After(files[j].RotatedAt)
+	})
+
+	return files
+}
+
+func (this *RotatingFile) removeOldBackups() {
+	var now time.Time = time.Now()
+
+	for index, file := range this.getRotatedFiles() {
+		var expired bool = this.MaxBackupAge > 0 && now.Sub(file.RotatedAt) > this.MaxBackupAge
+
+		if (this.MaxBackups > 0 && index >= this.MaxBackups) || expired {
+			os.Remove(file.Path)
+		}
+	}
+}
+
+func (this *RotatingFile) Write(data []byte) (int, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.closed {
+		return 0, os.ErrClosed
+	}
+
+	if this.file == nil {
+		if err := this.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	var now time.Time = time.Now()
+
+	if this.shouldRotate(now, len(data)) {
+		if err := this.rotate(now); err != nil && this.file == nil {
+			return 0, err
+		}
+	}
+
+	written, err := this.file.Write(data)
+
+	this.size += int64(written)
+
+	return written, err
+}
+
+// Rotates the file right away.
+func (this *RotatingFile) Rotate() error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.closed {
+		return os.ErrClosed
+	}
+
+	return this.rotate(time.Now())
+}
+
+// Closes the file and waits for pending compression and cleanup.
+func (this *RotatingFile) Close() error {
+	this.mutex.Lock()
+
+	var err error = nil
+
+	if this.file != nil {
+		err = this.file.Close()
+
+		this.file = nil
+	}
+
+	this.closed = true
+
+	this.mutex.Unlock()
+
+	this.maintenance.Wait()
+
+	return err
+}
@@ -0,0 +1,143 @@
+package Logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Destination of log records, every sink gets each record at or above its `Level`.
+type Sink struct {
+	Writer io.Writer
+
+	Format string
+	Level  ENUM_LOG_LEVEL
+}
+
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+var sinks []Sink = []Sink{}
+var sinksMutex sync.Mutex
+
+func NewConsoleSink(format string, level ENUM_LOG_LEVEL) Sink {
+	return Sink{Writer: os.Stdout, Format: format, Level: level}
+}
+
+func (this *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range this.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (this *fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error = []error{}
+
+	for _, handler := range this.handlers {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+
+		if err := handler.Handle(ctx, record.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (this *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var handlers []slog.Handler = []slog.Handler{}
+
+	for _, handler := range this.handlers {
+		handlers = append(handlers, handler.WithAttrs(attrs))
+	}
+
+	return &fanoutHandler{handlers: handlers}
+}
+
+func (this *fanoutHandler) WithGroup(name string) slog.Handler {
+	var handlers []slog.Handler = []slog.Handler{}
+
+	for _, handler := range this.handlers {
+		handlers = append(handlers, handler.WithGroup(name))
+	}
+
+	return &fanoutHandler{handlers: handlers}
+}
+
+func ValidateFormat(format string) error {
+	if len(format) > 0 && !strings.EqualFold(format, LOG_FORMAT_TEXT) && !strings.EqualFold(format, LOG_FORMAT_JSON) {
+		return errors.New("Unknown log format '" + format + "'")
+	}
+
+	return nil
+}
+
+func closeSinkWriter(writer io.Writer) {
+	if writer == os.Stdout || writer == os.Stderr {
+		return
+	}
+
+	if closer, ok := writer.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// Replaces the log destinations. Writers of the previous sinks that aren't reused
+// are closed once the records already being handled are written.
+func SetSinks(values []Sink) {
+	var handlers []slog.Handler = []slog.Handler{}
+
+	for _, sink := range values {
+		handlers = append(handlers, NewHandler(sink.Format, sink.Level, sink.Writer))
+	}
+
+	sinksMutex.Lock()
+	defer sinksMutex.Unlock()
+
+	SetHandler(&fanoutHandler{handlers: handlers})
+
+	// Records handled from now on use the new handler, waits for the rest.
+	handlerMutex.Lock()
+	handlerMutex.Unlock()
+
+	for _, previous := range sinks {
+		if !containsSinkWriter(values, previous.Writer) {
+			closeSinkWriter(previous.Writer)
+		}
+	}
+
+	sinks = append([]Sink{}, values...)
+}
+
+func GetSinks() []Sink {
+	sinksMutex.Lock()
+	defer sinksMutex.Unlock()
+
+	return append([]Sink{}, sinks...)
+}
+
+func containsSinkWriter(values []Sink, writer io.Writer) bool {
+	for _, sink := range values {
+		if sink.Writer == writer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Closes the file sinks and goes back to logging to the console.
+func CloseSinks() {
+	SetSinks([]Sink{NewConsoleSink(LOG_FORMAT_TEXT, LOG_LEVEL_DEBUG)})
+}
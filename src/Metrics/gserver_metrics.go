@@ -0,0 +1,78 @@
+package Metrics
+
+// Status label of upstream requests that failed before a response arrived.
+const UPSTREAM_STATUS_ERROR = "error"
+
+var UpstreamRequests *Vector = NewCounter(
+	"gserver_upstream_requests_total",
+	"Requests sent to upstream services, by source and response status.",
+	"source", "status",
+)
+
+var UpstreamRequestDuration *Vector = NewHistogram(
+	"gserver_upstream_request_duration_seconds",
+	"Latency of requests sent to upstream services.",
+	DefaultDurationBuckets,
+	"source",
+)
+
+var CrawlerPages *Vector = NewCounter(
+	"gserver_crawler_pages_total",
+	"Search result pages crawled, by crawler and result.",
+	"crawler", "result",
+)
+
+var MoviesParsed *Vector = NewCounter(
+	"gserver_movies_parsed_total",
+	"Movies parsed from upstream search results.",
+	"source",
+)
+
+var MoviesParseFailures *Vector = NewCounter(
+	"gserver_movies_parse_failures_total",
+	"Upstream search results that couldn't be parsed as movies.",
+	"source",
+)
+
+var TorrentsParsed *Vector = NewCounter(
+	"gserver_torrents_parsed_total",
+	"Torrent files downloaded and parsed.",
+	"source",
+)
+
+var TorrentsParseFailures *Vector = NewCounter(
+	"gserver_torrents_parse_failures_total",
+	"Torrent files that couldn't be downloaded or parsed.",
+	"source",
+)
+
+var TorrentDownloadBytes *Vector = NewCounter(
+	"gserver_torrent_download_bytes_total",
+	"Bytes of torrent files downloaded.",
+	"source",
+)
+
+var TaskManagerPendingTasks *Vector = NewGauge(
+	"gserver_task_manager_pending_tasks",
+	"Tasks queued in task managers, managers created per request are summed under their base name.",
+	"task_manager",
+)
+
+var TaskManagerRunningTasks *Vector = NewGauge(
+	"gserver_task_manager_running_tasks",
+	"Tasks running in task managers, managers created per request are summed under their base name.",
+	"task_manager",
+)
+
+var HttpRequests *Vector = NewCounter(
+	"gserver_http_requests_total",
+	"Requests served by the HTTP API, by endpoint, method and status.",
+	"path", "method", "status",
+)
+
+var HttpRequestDuration *Vector = NewHistogram(
+	"gserver_http_request_duration_seconds",
+	"Latency of requests served by the HTTP API. Streaming endpoints report the connection lifetime.",
+	DefaultDurationBuckets,
+	"path", "method",
+)
@@ -0,0 +1,257 @@
+package Metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	METRIC_TYPE_COUNTER   = "counter"
+	METRIC_TYPE_GAUGE     = "gauge"
+	METRIC_TYPE_HISTOGRAM = "histogram"
+
+	TEXT_FORMAT_CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// Prometheus' default buckets, in seconds.
+var DefaultDurationBuckets []float64 = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type Metric interface {
+	GetName() string
+	WriteText(writer io.Writer)
+}
+
+type series struct {
+	LabelValues []string
+
+	Value float64
+
+	// Histograms only, cumulative counts per bucket and the observation count.
+	BucketCounts []uint64
+	Count        uint64
+}
+
+// Counter, gauge or histogram, every combination of label values is its own series.
+type Vector struct {
+	Name string
+	Help string
+	Type string
+
+	LabelNames []string
+
+	Buckets []float64
+
+	series map[string]*series
+
+	mutex sync.Mutex
+}
+
+var registry []Metric = []Metric{}
+var registryMutex sync.Mutex
+
+func Register(metric Metric) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registry = append(registry, metric)
+}
+
+func newVector(name string, help string, metricType string, labelNames []string) *Vector {
+	var vector *Vector = new(Vector)
+
+	vector.Name = name
+	vector.Help = help
+	vector.Type = metricType
+
+	vector.LabelNames = labelNames
+
+	vector.Buckets = nil
+
+	vector.series = map[string]*series{}
+
+	Register(vector)
+
+	return vector
+}
+
+func NewCounter(name string, help string, labelNames ...string) *Vector {
+	return newVector(name, help, METRIC_TYPE_COUNTER, labelNames)
+}
+
+func NewGauge(name string, help string, labelNames ...string) *Vector {
+	return newVector(name, help, METRIC_TYPE_GAUGE, labelNames)
+}
+
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Vector {
+	var vector *Vector = newVector(name, help, METRIC_TYPE_HISTOGRAM, labelNames)
+
+	vector.Buckets = buckets
+
+	return vector
+}
+
+func (this *Vector) GetName() string {
+	return this.Name
+}
+
+// Must be called with the mutex held.
+func (this *Vector) getSeries(labelValues []string) *series {
+	if len(labelValues) != len(this.LabelNames) {
+		panic(fmt.Sprintf("Metric '%s' takes %d label values, got %d", this.Name, len(this.LabelNames), len(labelValues)))
+	}
+
+	var key string = strings.Join(labelValues, "\xff")
+
+	s, exists := this.series[key]
+
+	if !exists {
+		s = new(series)
+
+		s.LabelValues = append([]string{}, labelValues...)
+
+		s.Value = 0
+
+		s.BucketCounts = make([]uint64, len(this.Buckets))
+		s.Count = 0
+
+		this.series[key] = s
+	}
+
+	return s
+}
+
+func (this *Vector) Add(value float64, labelValues ...string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.getSeries(labelValues).Value += value
+}
+
+func (this *Vector) Inc(labelValues ...string) {
+	this.Add(1, labelValues...)
+}
+
+func (this *Vector) Set(value float64, labelValues ...string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.getSeries(labelValues).Value = value
+}
+
+// Drops every series, gauges filled from snapshots use it to forget labels
+// that went away.
+func (this *Vector) Reset() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.series = map[string]*series{}
+}
+
+func (this *Vector) Observe(value float64, labelValues ...string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	var s *series = this.getSeries(labelValues)
+
+	for index, bound := range this.Buckets {
+		if value <= bound {
+			s.BucketCounts[index]++
+		}
+	}
+
+	s.Count++
+	s.Value += value
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+func formatLabels(names []string, values []string, extraName string, extraValue string) string {
+	var pairs []string = []string{}
+
+	for index, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabelValue(values[index])+`"`)
+	}
+
+	if len(extraName) > 0 {
+		pairs = append(pairs, extraName+`="`+escapeLabelValue(extraValue)+`"`)
+	}
+
+	if len(pairs) < 1 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func (this *Vector) WriteText(writer io.Writer) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	fmt.Fprintf(writer, "# HELP %s %s\n", this.Name, strings.ReplaceAll(this.Help, "\n", " "))
+	fmt.Fprintf(writer, "# TYPE %s %s\n", this.Name, this.Type)
+
+	var keys []string = []string{}
+
+	for key := range this.series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var s *series = this.series[key]
+
+		if this.Type != METRIC_TYPE_HISTOGRAM {
+			fmt.Fprintf(writer, "%s%s %s\n", this.Name, formatLabels(this.LabelNames, s.LabelValues, "", ""), formatValue(s.Value))
+			continue
+		}
+
+		for index, bound := range this.Buckets {
+			fmt.Fprintf(writer, "%s_bucket%s %d\n", this.Name, formatLabels(this.LabelNames, s.LabelValues, "le", formatValue(bound)), s.BucketCounts[index])
+		}
+
+		fmt.Fprintf(writer, "%s_bucket%s %d\n", this.Name, formatLabels(this.LabelNames, s.LabelValues, "le", "+Inf"), s.Count)
+		fmt.Fprintf(writer, "%s_sum%s %s\n", this.Name, formatLabels(this.LabelNames, s.LabelValues, "", ""), formatValue(s.Value))
+		fmt.Fprintf(writer, "%s_count%s %d\n", this.Name, formatLabels(this.LabelNames, s.LabelValues, "", ""), s.Count)
+	}
+}
+
+// Writes every registered metric in the Prometheus text exposition format.
+func WriteText(writer io.Writer) error {
+	registryMutex.Lock()
+
+	var metrics []Metric = append([]Metric{}, registry...)
+
+	registryMutex.Unlock()
+
+	sort.Slice(metrics, func(i int, j int) bool {
+		return metrics[i].GetName() < metrics[j].GetName()
+	})
+
+	var buffered *bufio.Writer = bufio.NewWriter(writer)
+
+	for _, metric := range metrics {
+		metric.WriteText(buffered)
+	}
+
+	return buffered.Flush()
+}
@@ -2,12 +2,32 @@ package Movie
 
 const (
 	INVALID_MOVIE_DETAIL_ID = 0
+
+	MOVIE_SOURCE_UNKNOWN          = ""
+	MOVIE_SOURCE_YTS              = "YTS"
+	MOVIE_SOURCE_INTERNET_ARCHIVE = "InternetArchive"
 )
 
+type MovieCastInfo struct {
+	Name          string
+	CharacterName string
+
+	IMDBCode string
+
+	SmallImage string
+}
+
 type MovieDetails struct {
 	Id                float64
 	SpecialIdentifier string
 
+	Source  string
+	Sources []string
+
+	// Source each field value was taken from once records are merged, fields
+	// missing from it come from `Source`.
+	Provenance map[string]string
+
 	URL string
 
 	IMDBCode string
@@ -17,12 +37,18 @@ type MovieDetails struct {
 	TitleLong    string
 	Slug         string
 
+	CleanTitle string
+	SortTitle  string
+	SearchKey  string
+
 	Year    float64
 	Rating  float64
 	Runtime float64
 
 	Genres []string
 
+	Cast []*MovieCastInfo
+
 	LikeCount float64
 
 	Summery          string
@@ -58,6 +84,11 @@ func NewMovieDetails() *MovieDetails {
 	details.Id = INVALID_MOVIE_DETAIL_ID
 	details.SpecialIdentifier = ""
 
+	details.Source = MOVIE_SOURCE_UNKNOWN
+	details.Sources = []string{}
+
+	details.Provenance = map[string]string{}
+
 	details.URL = ""
 
 	details.IMDBCode = ""
@@ -67,12 +98,18 @@ func NewMovieDetails() *MovieDetails {
 	details.TitleLong = ""
 	details.Slug = ""
 
+	details.CleanTitle = ""
+	details.SortTitle = ""
+	details.SearchKey = ""
+
 	details.Year = 0
 	details.Rating = 0.0
 	details.Runtime = 0
 
 	details.Genres = nil
 
+	details.Cast = nil
+
 	details.LikeCount = 0
 
 	details.Summery = ""
@@ -104,6 +141,19 @@ func NewMovieDetails() *MovieDetails {
 	return details
 }
 
+func NewMovieCastInfo() *MovieCastInfo {
+	var castInfo *MovieCastInfo = new(MovieCastInfo)
+
+	castInfo.Name = ""
+	castInfo.CharacterName = ""
+
+	castInfo.IMDBCode = ""
+
+	castInfo.SmallImage = ""
+
+	return castInfo
+}
+
 func IsMovieDetialsValid(details *MovieDetails) bool {
 	if details == nil {
 		return false
@@ -0,0 +1,352 @@
+package Movie
+
+import (
+	"strings"
+)
+
+const (
+	MOVIE_MATCH_SCORE_NONE      = 0.0
+	MOVIE_MATCH_SCORE_THRESHOLD = 0.75
+	MOVIE_MATCH_SCORE_IMDB_CODE = 1.0
+	MOVIE_MATCH_SCORE_TITLE     = 0.9
+
+	MOVIE_MATCH_MINIMUM_TITLE_SIMILARITY = 0.85
+	MOVIE_MATCH_MAXIMUM_YEAR_DISTANCE    = 1
+)
+
+// Sources listed first win when two records both have a value for a field. YTS is
+// curated so it wins for metadata, Internet Archive items know their own size
+// better than any mirror.
+var MovieSourcePrecedence map[string][]string = map[string][]string{
+	"":     {MOVIE_SOURCE_YTS, MOVIE_SOURCE_INTERNET_ARCHIVE},
+	"Size": {MOVIE_SOURCE_INTERNET_ARCHIVE, MOVIE_SOURCE_YTS},
+}
+
+func getMovieSourcePriority(field string, source string) int {
+	precedence, exists := MovieSourcePrecedence[field]
+
+	if !exists {
+		precedence = MovieSourcePrecedence[""]
+	}
+
+	for index, s := range precedence {
+		if s == source {
+			return index
+		}
+	}
+
+	return len(precedence)
+}
+
+func GetMovieFieldSource(details *MovieDetails, field string) string {
+	if source, exists := details.Provenance[field]; exists {
+		return source
+	}
+
+	return details.Source
+}
+
+func mergeMovieField[T comparable](target *MovieDetails, other *MovieDetails, targetValue *T, otherValue T, field string) {
+	var empty T
+
+	if otherValue == empty {
+		return
+	}
+
+	var otherSource string = GetMovieFieldSource(other, field)
+
+	if *targetValue != empty && getMovieSourcePriority(field, GetMovieFieldSource(target, field)) <= getMovieSourcePriority(field, otherSource) {
+		return
+	}
+
+	*targetValue = otherValue
+
+	target.Provenance[field] = otherSource
+}
+
+func mergeMovieListField(target *MovieDetails, other *MovieDetails, targetValue *[]string, otherValue []string, field string) {
+	if len(otherValue) < 1 {
+		return
+	}
+
+	var otherSource string = GetMovieFieldSource(other, field)
+
+	if len(*targetValue) > 0 && getMovieSourcePriority(field, GetMovieFieldSource(target, field)) <= getMovieSourcePriority(field, otherSource) {
+		return
+	}
+
+	*targetValue = append([]string{}, otherValue...)
+
+	target.Provenance[field] = otherSource
+}
+
+func getTorrentMergeKey(torrent *MovieTorrentInfo) string {
+	if len(torrent.Hash) > 0 {
+		return strings.ToLower(torrent.Hash)
+	}
+
+	return torrent.URL
+}
+
+// Returns a copy of `details` that can be merged into without changing the
+// original, torrents are shared as they are never modified after parsing.
+func CopyMovieDetails(details *MovieDetails) *MovieDetails {
+	var copied *MovieDetails = new(MovieDetails)
+
+	*copied = *details
+
+	copied.Sources = append([]string{}, details.Sources...)
+	copied.Genres = append([]string(nil), details.Genres...)
+	copied.Torrents = append([]*MovieTorrentInfo(nil), details.Torrents...)
+
+	copied.Provenance = map[string]string{}
+
+	for field, source := range details.Provenance {
+		copied.Provenance[field] = source
+	}
+
+	return copied
+}
+
+// Merges `other` into `target` field by field following MovieSourcePrecedence and
+// unions their torrents by info-hash, recording where every taken value came from.
+func MergeMovieDetails(target *MovieDetails, other *MovieDetails) {
+	if target == nil || other == nil {
+		return
+	}
+
+	if target.Provenance == nil {
+		target.Provenance = map[string]string{}
+	}
+
+	mergeMovieField(target, other, &target.Id, other.Id, "Id")
+	mergeMovieField(target, other, &target.SpecialIdentifier, other.SpecialIdentifier, "SpecialIdentifier")
+
+	mergeMovieField(target, other, &target.URL, other.URL, "URL")
+
+	mergeMovieField(target, other, &target.IMDBCode, other.IMDBCode, "IMDBCode")
+
+	mergeMovieField(target, other, &target.Title, other.Title, "Title")
+	mergeMovieField(target, other, &target.TitleEnglish, other.TitleEnglish, "TitleEnglish")
+	mergeMovieField(target, other, &target.TitleLong, other.TitleLong, "TitleLong")
+	mergeMovieField(target, other, &target.Slug, other.Slug, "Slug")
+
+	mergeMovieField(target, other, &target.Year, other.Year, "Year")
+	mergeMovieField(target, other, &target.Rating, other.Rating, "Rating")
+	mergeMovieField(target, other, &target.Runtime, other.Runtime, "Runtime")
+
+	mergeMovieListField(target, other, &target.Genres, other.Genres, "Genres")
+
+	if len(other.Cast) > 0 && (len(target.Cast) < 1 || getMovieSourcePriority("Cast", GetMovieFieldSource(target, "Cast")) > getMovieSourcePriority("Cast", GetMovieFieldSource(other, "Cast"))) {
+		target.Cast = other.Cast
+
+		target.Provenance["Cast"] = GetMovieFieldSource(other, "Cast")
+	}
+
+	mergeMovieField(target, other, &target.LikeCount, other.LikeCount, "LikeCount")
+
+	mergeMovieField(target, other, &target.Summery, other.Summery, "Summery")
+	mergeMovieField(target, other, &target.DescriptionIntro, other.DescriptionIntro, "DescriptionIntro")
+	mergeMovieField(target, other, &target.DescriptionFull, other.DescriptionFull, "DescriptionFull")
+	mergeMovieField(target, other, &target.Synopsis, other.Synopsis, "Synopsis")
+
+	mergeMovieField(target, other, &target.YTTrailerCode, other.YTTrailerCode, "YTTrailerCode")
+
+	mergeMovieField(target, other, &target.Language, other.Language, "Language")
+
+	mergeMovieField(target, other, &target.MPARating, other.MPARating, "MPARating")
+
+	mergeMovieField(target, other, &target.BackgroundImage, other.BackgroundImage, "BackgroundImage")
+	mergeMovieField(target, other, &target.BackgroundImageOriginal, other.BackgroundImageOriginal, "BackgroundImageOriginal")
+	mergeMovieField(target, other, &target.SmallCoverImage, other.SmallCoverImage, "SmallCoverImage")
+	mergeMovieField(target, other, &target.MediumCoverImage, other.MediumCoverImage, "MediumCoverImage")
+	mergeMovieField(target, other, &target.LargeCoverImage, other.LargeCoverImage, "LargeCoverImage")
+
+	mergeMovieField(target, other, &target.State, other.State, "State")
+
+	mergeMovieField(target, other, &target.Size, other.Size, "Size")
+
+	mergeMovieField(target, other, &target.DateUploaded, other.DateUploaded, "DateUploaded")
+	mergeMovieField(target, other, &target.DateUploadedUnix, other.DateUploadedUnix, "DateUploadedUnix")
+
+	var torrentKeys map[string]bool = map[string]bool{}
+
+	for _, torrent := range target.Torrents {
+		torrentKeys[getTorrentMergeKey(torrent)] = true
+	}
+
+	for _, torrent := range other.Torrents {
+		var key string = getTorrentMergeKey(torrent)
+
+		if torrentKeys[key] {
+			continue
+		}
+
+		torrentKeys[key] = true
+
+		target.Torrents = append(target.Torrents, torrent)
+	}
+
+	var sources []string = []string{target.Source, other.Source}
+
+	sources = append(sources, other.Sources...)
+
+	for _, source := range sources {
+		if len(source) < 1 {
+			continue
+		}
+
+		var exists bool = false
+
+		for _, s := range target.Sources {
+			if s == source {
+				exists = true
+				break
+			}
+		}
+
+		if !exists {
+			target.Sources = append(target.Sources, source)
+		}
+	}
+}
+
+func normalizeMovieMatchTitle(title string) string {
+	var tokens []string = SplitSearchTokens(title)
+
+	if len(tokens) > 1 && (tokens[0] == "the" || tokens[0] == "a" || tokens[0] == "an") {
+		tokens = tokens[1:]
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+type MovieMatchKey struct {
+	IMDBCode string
+
+	Title string
+	Year  float64
+}
+
+func GetMovieMatchKey(details *MovieDetails) *MovieMatchKey {
+	var key *MovieMatchKey = new(MovieMatchKey)
+
+	key.IMDBCode = strings.ToLower(details.IMDBCode)
+
+	key.Title, key.Year = GetCleanMovieTitle(details.Title, details.Year)
+
+	key.Title = normalizeMovieMatchTitle(key.Title)
+
+	return key
+}
+
+func getLevenshteinDistance(a []rune, b []rune) int {
+	var previous []int = make([]int, len(b)+1)
+	var current []int = make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			var cost int = 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func GetTitleSimilarity(a string, b string) float64 {
+	var aRunes []rune = []rune(a)
+	var bRunes []rune = []rune(b)
+
+	var length int = max(len(aRunes), len(bRunes))
+
+	if length == 0 {
+		return 1
+	}
+
+	return 1 - float64(getLevenshteinDistance(aRunes, bRunes))/float64(length)
+}
+
+// Scores how likely two records describe the same film, from MOVIE_MATCH_SCORE_NONE
+// to MOVIE_MATCH_SCORE_IMDB_CODE. IMDb codes decide alone when both records have one.
+func GetMovieMatchKeyScore(a *MovieMatchKey, b *MovieMatchKey) float64 {
+	if a == nil || b == nil {
+		return MOVIE_MATCH_SCORE_NONE
+	}
+
+	if len(a.IMDBCode) > 0 && len(b.IMDBCode) > 0 {
+		if a.IMDBCode == b.IMDBCode {
+			return MOVIE_MATCH_SCORE_IMDB_CODE
+		}
+
+		return MOVIE_MATCH_SCORE_NONE
+	}
+
+	if len(a.Title) < 1 || len(b.Title) < 1 {
+		return MOVIE_MATCH_SCORE_NONE
+	}
+
+	var yearScore float64 = 1
+
+	if a.Year != 0 && b.Year != 0 {
+		var distance float64 = a.Year - b.Year
+
+		if distance < 0 {
+			distance = -distance
+		}
+
+		if distance > MOVIE_MATCH_MAXIMUM_YEAR_DISTANCE {
+			return MOVIE_MATCH_SCORE_NONE
+		}
+
+		yearScore -= distance * 0.1
+	} else {
+		// Without both years only the title speaks, remakes share their titles.
+		yearScore = 0.9
+	}
+
+	if a.Title == b.Title {
+		return MOVIE_MATCH_SCORE_TITLE * yearScore
+	}
+
+	// Titles too different in length can't reach the minimum similarity.
+	var aLength float64 = float64(len(a.Title))
+	var bLength float64 = float64(len(b.Title))
+
+	if min(aLength, bLength)/max(aLength, bLength) < MOVIE_MATCH_MINIMUM_TITLE_SIMILARITY {
+		return MOVIE_MATCH_SCORE_NONE
+	}
+
+	var similarity float64 = GetTitleSimilarity(a.Title, b.Title)
+
+	if similarity < MOVIE_MATCH_MINIMUM_TITLE_SIMILARITY {
+		return MOVIE_MATCH_SCORE_NONE
+	}
+
+	return MOVIE_MATCH_SCORE_TITLE * similarity * yearScore
+}
+
+func GetMovieMatchScore(a *MovieDetails, b *MovieDetails) float64 {
+	if a == nil || b == nil {
+		return MOVIE_MATCH_SCORE_NONE
+	}
+
+	return GetMovieMatchKeyScore(GetMovieMatchKey(a), GetMovieMatchKey(b))
+}
+
+func IsSameMovie(a *MovieDetails, b *MovieDetails) bool {
+	return GetMovieMatchScore(a, b) >= MOVIE_MATCH_SCORE_THRESHOLD
+}
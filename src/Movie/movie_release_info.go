@@ -0,0 +1,474 @@
+package Movie
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	RELEASE_SOURCE_CAM    = "CAM"
+	RELEASE_SOURCE_TS     = "TS"
+	RELEASE_SOURCE_DVD    = "DVD"
+	RELEASE_SOURCE_HDTV   = "HDTV"
+	RELEASE_SOURCE_WEB    = "WEB"
+	RELEASE_SOURCE_WEBRIP = "WEBRip"
+	RELEASE_SOURCE_BLURAY = "BluRay"
+	RELEASE_SOURCE_REMUX  = "Remux"
+
+	RELEASE_CODEC_XVID = "XviD"
+	RELEASE_CODEC_X264 = "x264"
+	RELEASE_CODEC_X265 = "x265"
+	RELEASE_CODEC_AV1  = "AV1"
+	RELEASE_CODEC_VP9  = "VP9"
+)
+
+type MovieReleaseInfo struct {
+	Title string
+	Year  float64
+
+	Resolution string
+	Is3D       bool
+
+	Source string
+
+	VideoCodec string
+	BitDepth   string
+
+	HDR []string
+
+	AudioFormat   string
+	AudioChannels string
+
+	IsRepack bool
+	IsProper bool
+
+	Edition string
+
+	ReleaseGroup string
+}
+
+type releaseNamePattern struct {
+	Expression *regexp.Regexp
+	Value      string
+}
+
+// Every pattern is wrapped so that it only matches whole tokens, the separators
+// used by release names being dots, spaces, underscores, dashes and brackets.
+func newReleaseNameExpression(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[\s._\-\[\](),+])(?:` + pattern + `)(?:$|[\s._\-\[\](),+])`)
+}
+
+func newReleaseNamePatterns(patterns ...string) []releaseNamePattern {
+	var result []releaseNamePattern = []releaseNamePattern{}
+
+	for i := 0; i+1 < len(patterns); i += 2 {
+		result = append(result, releaseNamePattern{Expression: newReleaseNameExpression(patterns[i]), Value: patterns[i+1]})
+	}
+
+	return result
+}
+
+var releaseResolutionPatterns []releaseNamePattern = newReleaseNamePatterns(
+	`2160p|4k|uhd`, "2160p",
+	`1440p`, "1440p",
+	`1080p|1080i|fhd`, "1080p",
+	`720p`, "720p",
+	`576p`, "576p",
+	`480p|sd`, "480p",
+)
+
+var releaseSourcePatterns []releaseNamePattern = newReleaseNamePatterns(
+	`remux|bdremux`, RELEASE_SOURCE_REMUX,
+	`blu-?ray|bluray|bdrip|brrip|bd25|bd50`, RELEASE_SOURCE_BLURAY,
+	`web-?rip|webrip`, RELEASE_SOURCE_WEBRIP,
+	`web-?dl|webdl|web|amzn|nf|dsnp|hmax|atvp`, RELEASE_SOURCE_WEB,
+	`hdtv|pdtv|tvrip|hdtvrip`, RELEASE_SOURCE_HDTV,
+	`dvdrip|dvd-?r|dvd5|dvd9|dvd`, RELEASE_SOURCE_DVD,
+	`hdts|telesync|ts|tc|telecine`, RELEASE_SOURCE_TS,
+	`hdcam|cam|camrip`, RELEASE_SOURCE_CAM,
+)
+
+var releaseCodecPatterns []releaseNamePattern = newReleaseNamePatterns(
+	`x265|h\.?265|hevc`, RELEASE_CODEC_X265,
+	`x264|h\.?264|avc`, RELEASE_CODEC_X264,
+	`av1`, RELEASE_CODEC_AV1,
+	`vp9`, RELEASE_CODEC_VP9,
+	`xvid|divx`, RELEASE_CODEC_XVID,
+)
+
+var releaseHDRPatterns []releaseNamePattern = newReleaseNamePatterns(
+	`hdr10\+|hdr10plus`, "HDR10+",
+	`hdr10|hdr`, "HDR10",
+	`dv|dovi|dolby[\s._-]?vision`, "DV",
+	`hlg`, "HLG",
+)
+
+// Audio tags are often glued to their channel layout, as in "DDP5.1" or "AAC2.0".
+var releaseAudioPatterns []releaseNamePattern = newReleaseNamePatterns(
+	`(?:truehd|true-hd)(?:\d[.\s]\d)?`, "TrueHD",
+	`atmos`, "Atmos",
+	`(?:dts-?hd(?:[\s._-]?ma)?|dts-?x)(?:\d[.\s]\d)?`, "DTS-HD",
+	`dts(?:\d[.\s]\d)?`, "DTS",
+	`(?:ddp|dd\+|e-?ac-?3|eac3)(?:\d[.\s]\d)?`, "DDP",
+	`(?:dd|ac-?3|dolby[\s._-]?digital)(?:\d[.\s]\d)?`, "AC3",
+	`flac(?:\d[.\s]\d)?`, "FLAC",
+	`aac(?:\d[.\s]\d)?`, "AAC",
+	`opus(?:\d[.\s]\d)?`, "Opus",
+	`mp3`, "MP3",
+)
+
+var releaseEditionPatterns []releaseNamePattern = newReleaseNamePatterns(
+	`director'?s[\s._-]?cut`, "Director's Cut",
+	`extended(?:[\s._-]?(?:cut|edition))?`, "Extended",
+	`unrated`, "Unrated",
+	`uncut`, "Uncut",
+	`theatrical(?:[\s._-]?cut)?`, "Theatrical",
+	`remastered`, "Remastered",
+	`imax`, "IMAX",
+	`criterion`, "Criterion",
+	`special[\s._-]?edition`, "Special Edition",
+	`anniversary[\s._-]?edition`, "Anniversary Edition",
+)
+
+var releaseAudioChannelsExpression *regexp.Regexp = regexp.MustCompile(`(?i)(?:^|[\s._\-\[\](),+]|aac|ac-?3|ddp?|dd\+|dts|flac|opus|truehd|eac3)(?:([1-9])[\s.]([01])(?:ch)?|([2-8])ch)(?:$|[\s._\-\[\])])`)
+var releaseBitDepthExpression *regexp.Regexp = newReleaseNameExpression(`(8|10|12)[\s.-]?bits?`)
+var releaseRepackExpression *regexp.Regexp = newReleaseNameExpression(`repack\d?|rerip`)
+var releaseProperExpression *regexp.Regexp = newReleaseNameExpression(`proper`)
+var release3DExpression *regexp.Regexp = newReleaseNameExpression(`3d|hsbs|h-sbs|half-sbs|hou|sbs`)
+var releaseYearExpression *regexp.Regexp = regexp.MustCompile(`(?:^|[\s._\-\[(])((?:19|20)\d{2})(?:$|[\s._\-\])])`)
+var releaseDashGroupExpression *regexp.Regexp = regexp.MustCompile(`-\s*([A-Za-z0-9][A-Za-z0-9.&]*?)\s*(?:\[[^\]]*\])?$`)
+var releaseBracketGroupExpression *regexp.Regexp = regexp.MustCompile(`\[([^\]]+)\]\s*$`)
+
+func NewMovieReleaseInfo() *MovieReleaseInfo {
+	var release *MovieReleaseInfo = new(MovieReleaseInfo)
+
+	release.Title = ""
+	release.Year = 0
+
+	release.Resolution = ""
+	release.Is3D = false
+
+	release.Source = ""
+
+	release.VideoCodec = ""
+	release.BitDepth = ""
+
+	release.HDR = []string{}
+
+	release.AudioFormat = ""
+	release.AudioChannels = ""
+
+	release.IsRepack = false
+	release.IsProper = false
+
+	release.Edition = ""
+
+	release.ReleaseGroup = ""
+
+	return release
+}
+
+func matchReleaseNamePatterns(name string, patterns []releaseNamePattern) (string, int) {
+	var value string = ""
+	var position int = -1
+
+	for _, pattern := range patterns {
+		location := pattern.Expression.FindStringIndex(name)
+
+		if location == nil {
+			continue
+		}
+
+		if position < 0 || location[0] < position {
+			value = pattern.Value
+			position = location[0]
+		}
+	}
+
+	return value, position
+}
+
+func stripReleaseNameExtension(name string) string {
+	var extension string = path.Ext(name)
+
+	if len(extension) < 2 || len(extension) > 5 {
+		return name
+	}
+
+	for _, r := range extension[1:] {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return name
+		}
+	}
+
+	// "Movie.5.1" or "Movie.2019" are not extensions.
+	if _, err := strconv.Atoi(extension[1:]); err == nil {
+		return name
+	}
+
+	return name[:len(name)-len(extension)]
+}
+
+func isReleaseNameTag(value string) bool {
+	var tag string = " " + value + " "
+
+	for _, patterns := range [][]releaseNamePattern{releaseResolutionPatterns, releaseSourcePatterns, releaseCodecPatterns, releaseHDRPatterns, releaseAudioPatterns, releaseEditionPatterns} {
+		for _, pattern := range patterns {
+			if pattern.Expression.MatchString(tag) {
+				return true
+			}
+		}
+	}
+
+	return releaseAudioChannelsExpression.MatchString(tag) || releaseYearExpression.MatchString(tag)
+}
+
+func cleanReleaseTitle(title string) string {
+	title = strings.NewReplacer(".", " ", "_", " ").Replace(title)
+	title = strings.Trim(title, " -[(")
+
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// Extracts the structured release attributes from a torrent or file name such as
+// "The.Movie.2019.REPACK.1080p.BluRay.x265.10bit.DDP5.1-GROUP.mkv".
+func ParseReleaseName(name string) *MovieReleaseInfo {
+	release, _ := parseReleaseName(name)
+
+	return release
+}
+
+// Also returns whether the year is set apart like a release tag, "(2000)", ".2000."
+// or followed by other tags, rather than ending a title like "Death Race 2000".
+func parseReleaseName(name string) (*MovieReleaseInfo, bool) {
+	var release *MovieReleaseInfo = NewMovieReleaseInfo()
+	var yearIsTag bool = false
+
+	name = strings.TrimSpace(stripReleaseNameExtension(name))
+
+	if len(name) < 1 {
+		return release, yearIsTag
+	}
+
+	var titleEnd int = len(name)
+
+	updateTitleEnd := func(position int) {
+		if position >= 0 && position < titleEnd {
+			titleEnd = position
+		}
+	}
+
+	var position int
+
+	release.Resolution, position = matchReleaseNamePatterns(name, releaseResolutionPatterns)
+	updateTitleEnd(position)
+
+	release.Source, position = matchReleaseNamePatterns(name, releaseSourcePatterns)
+	updateTitleEnd(position)
+
+	release.VideoCodec, position = matchReleaseNamePatterns(name, releaseCodecPatterns)
+	updateTitleEnd(position)
+
+	release.AudioFormat, position = matchReleaseNamePatterns(name, releaseAudioPatterns)
+	updateTitleEnd(position)
+
+	release.Edition, position = matchReleaseNamePatterns(name, releaseEditionPatterns)
+	updateTitleEnd(position)
+
+	for _, pattern := range releaseHDRPatterns {
+		location := pattern.Expression.FindStringIndex(name)
+
+		if location == nil {
+			continue
+		}
+
+		// "HDR10+" also matches the plain "HDR10" pattern.
+		if pattern.Value == "HDR10" && len(release.HDR) > 0 && release.HDR[0] == "HDR10+" {
+			continue
+		}
+
+		release.HDR = append(release.HDR, pattern.Value)
+
+		updateTitleEnd(location[0])
+	}
+
+	if match := releaseAudioChannelsExpression.FindStringSubmatchIndex(name); match != nil {
+		var groups []string = releaseAudioChannelsExpression.FindStringSubmatch(name)
+
+		if len(groups[1]) > 0 {
+			release.AudioChannels = groups[1] + "." + groups[2]
+		} else {
+			var count, _ = strconv.Atoi(groups[3])
+
+			release.AudioChannels = strconv.Itoa(count-1) + ".1"
+
+			if count <= 2 {
+				release.AudioChannels = strconv.Itoa(count) + ".0"
+			}
+		}
+
+		updateTitleEnd(match[0])
+	}
+
+	if groups := releaseBitDepthExpression.FindStringSubmatch(name); groups != nil {
+		release.BitDepth = groups[1]
+	}
+
+	if location := releaseRepackExpression.FindStringIndex(name); location != nil {
+		release.IsRepack = true
+
+		updateTitleEnd(location[0])
+	}
+
+	if location := releaseProperExpression.FindStringIndex(name); location != nil {
+		release.IsProper = true
+
+		updateTitleEnd(location[0])
+	}
+
+	if location := release3DExpression.FindStringIndex(name); location != nil {
+		release.Is3D = true
+
+		updateTitleEnd(location[0])
+	}
+
+	// The year closest to the release tags wins, "2001 A Space Odyssey 1968" is from 1968
+	// and "1917 2019" is from 2019. A leading number alone is always part of the title.
+	var yearStart int = -1
+
+	for start := titleEnd - 4; start > 0; start-- {
+		if location := releaseYearExpression.FindStringSubmatchIndex(name[start-1 : titleEnd]); location != nil && location[2] == 1 {
+			yearStart = start
+			break
+		}
+	}
+
+	if yearStart > 0 {
+		year, _ := strconv.Atoi(name[yearStart : yearStart+4])
+
+		release.Year = float64(year)
+
+		titleEnd = yearStart - 1
+
+		yearIsTag = strings.ContainsRune("([._", rune(name[titleEnd])) || len(strings.Trim(name[yearStart+4:], " )]")) > 0
+	}
+
+	release.Title = cleanReleaseTitle(name[:titleEnd])
+
+	// Groups are only looked for after the title, "Spider-Man" has no "Man" group.
+	var tags string = name[titleEnd:]
+
+	if groups := releaseDashGroupExpression.FindStringSubmatch(tags); groups != nil && !isReleaseNameTag(groups[1]) {
+		release.ReleaseGroup = groups[1]
+	} else if groups := releaseBracketGroupExpression.FindStringSubmatch(tags); groups != nil && !isReleaseNameTag(groups[1]) {
+		release.ReleaseGroup = groups[1]
+	}
+
+	return release, yearIsTag
+}
+
+// Fills the fields of `target` that are still empty with the values from `other`.
+func MergeMovieReleaseInfo(target *MovieReleaseInfo, other *MovieReleaseInfo) {
+	if target == nil || other == nil {
+		return
+	}
+
+	if len(target.Title) < 1 {
+		target.Title = other.Title
+	}
+
+	if target.Year == 0 {
+		target.Year = other.Year
+	}
+
+	if len(target.Resolution) < 1 {
+		target.Resolution = other.Resolution
+	}
+
+	target.Is3D = target.Is3D || other.Is3D
+
+	if len(target.Source) < 1 {
+		target.Source = other.Source
+	}
+
+	if len(target.VideoCodec) < 1 {
+		target.VideoCodec = other.VideoCodec
+	}
+
+	if len(target.BitDepth) < 1 {
+		target.BitDepth = other.BitDepth
+	}
+
+	if len(target.HDR) < 1 {
+		target.HDR = other.HDR
+	}
+
+	if len(target.AudioFormat) < 1 {
+		target.AudioFormat = other.AudioFormat
+	}
+
+	if len(target.AudioChannels) < 1 {
+		target.AudioChannels = other.AudioChannels
+	}
+
+	target.IsRepack = target.IsRepack || other.IsRepack
+	target.IsProper = target.IsProper || other.IsProper
+
+	if len(target.Edition) < 1 {
+		target.Edition = other.Edition
+	}
+
+	if len(target.ReleaseGroup) < 1 {
+		target.ReleaseGroup = other.ReleaseGroup
+	}
+}
+
+// Parses the torrent name and its main file name and fills the torrent fields the
+// source didn't provide, values coming from the source are never overwritten.
+func FillMissingTorrentReleaseFields(torrentInfo *MovieTorrentInfo) {
+	if torrentInfo == nil {
+		return
+	}
+
+	var release *MovieReleaseInfo = ParseReleaseName(torrentInfo.Name)
+
+	if torrentInfo.MainFile != nil {
+		MergeMovieReleaseInfo(release, ParseReleaseName(torrentInfo.MainFile.Name))
+	}
+
+	torrentInfo.Release = release
+
+	if len(torrentInfo.Quality) < 1 {
+		torrentInfo.Quality = release.Resolution
+
+		if release.Is3D {
+			torrentInfo.Quality = "3D"
+		}
+	}
+
+	if len(torrentInfo.Type) < 1 {
+		torrentInfo.Type = strings.ToLower(release.Source)
+	}
+
+	if len(torrentInfo.VideoCodec) < 1 {
+		torrentInfo.VideoCodec = release.VideoCodec
+	}
+
+	if len(torrentInfo.BitDepth) < 1 {
+		torrentInfo.BitDepth = release.BitDepth
+	}
+
+	if len(torrentInfo.AudioChannels) < 1 {
+		torrentInfo.AudioChannels = release.AudioChannels
+	}
+
+	if len(torrentInfo.IsRepack) < 1 {
+		torrentInfo.IsRepack = "0"
+
+		if release.IsRepack || release.IsProper {
+			torrentInfo.IsRepack = "1"
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package Movie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReleaseName(t *testing.T) {
+	var tests []struct {
+		Name     string
+		Expected MovieReleaseInfo
+	} = []struct {
+		Name     string
+		Expected MovieReleaseInfo
+	}{
+		{
+			Name: "The.Movie.2019.REPACK.1080p.BluRay.x265.10bit.DDP5.1-GROUP.mkv",
+			Expected: MovieReleaseInfo{
+				Title: "The Movie", Year: 2019,
+				Resolution: "1080p", Source: "BluRay", VideoCodec: "x265", BitDepth: "10",
+				AudioFormat: "DDP", AudioChannels: "5.1",
+				IsRepack: true, ReleaseGroup: "GROUP",
+			},
+		},
+		{
+			Name: "2001 A Space Odyssey 1968 720p BRRip",
+			Expected: MovieReleaseInfo{
+				Title: "2001 A Space Odyssey", Year: 1968,
+				Resolution: "720p", Source: "BluRay",
+			},
+		},
+		{
+			Name: "1917 2019 1080p WEBRip x264",
+			Expected: MovieReleaseInfo{
+				Title: "1917", Year: 2019,
+				Resolution: "1080p", Source: "WEBRip", VideoCodec: "x264",
+			},
+		},
+		{
+			Name: "Spider-Man.2002.2160p.UHD.BluRay.HDR10+.DTS-HD.MA.5.1-FGT",
+			Expected: MovieReleaseInfo{
+				Title: "Spider-Man", Year: 2002,
+				Resolution: "2160p", Source: "BluRay", HDR: []string{"HDR10+"},
+				AudioFormat: "DTS-HD", AudioChannels: "5.1",
+				ReleaseGroup: "FGT",
+			},
+		},
+		{
+			Name: "Nosferatu (1922) [1080p] [YTS.MX]",
+			Expected: MovieReleaseInfo{
+				Title: "Nosferatu", Year: 1922,
+				Resolution: "1080p", ReleaseGroup: "YTS.MX",
+			},
+		},
+		{
+			Name: "Movie.Name.3D.HSBS.1080p.WEB-DL.AAC2.0.H.264-EVO",
+			Expected: MovieReleaseInfo{
+				Title:      "Movie Name",
+				Resolution: "1080p", Is3D: true, Source: "WEB", VideoCodec: "x264",
+				AudioFormat: "AAC", AudioChannels: "2.0",
+				ReleaseGroup: "EVO",
+			},
+		},
+		{
+			Name:     "",
+			Expected: MovieReleaseInfo{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var release MovieReleaseInfo = *ParseReleaseName(test.Name)
+
+			if len(release.HDR) < 1 {
+				release.HDR = nil
+			}
+
+			if !reflect.DeepEqual(release, test.Expected) {
+				t.Errorf("ParseReleaseName(%q) = %+v, expected %+v", test.Name, release, test.Expected)
+			}
+		})
+	}
+}
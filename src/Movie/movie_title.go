@@ -0,0 +1,190 @@
+package Movie
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Leading articles moved to the end of sort titles, "The Matrix" sorts as "Matrix, The".
+var movieTitleArticles []string = []string{"the", "a", "an", "le", "la", "les", "el", "los", "las", "il", "der", "die", "das"}
+
+var asciiFoldReplacer *strings.Replacer = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "ā", "a", "ą", "a",
+	"À", "A", "Á", "A", "Â", "A", "Ã", "A", "Ä", "A", "Å", "A", "Ā", "A", "Ą", "A",
+	"æ", "ae", "Æ", "AE", "œ", "oe", "Œ", "OE", "ß", "ss",
+	"ç", "c", "ć", "c", "č", "c", "Ç", "C", "Ć", "C", "Č", "C",
+	"ď", "d", "đ", "d", "ð", "d", "Ď", "D", "Đ", "D", "Ð", "D",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ē", "e", "ę", "e", "ě", "e",
+	"È", "E", "É", "E", "Ê", "E", "Ë", "E", "Ē", "E", "Ę", "E", "Ě", "E",
+	"ğ", "g", "Ğ", "G",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ī", "i", "ı", "i",
+	"Ì", "I", "Í", "I", "Î", "I", "Ï", "I", "Ī", "I", "İ", "I",
+	"ł", "l", "Ł", "L",
+	"ñ", "n", "ń", "n", "ň", "n", "Ñ", "N", "Ń", "N", "Ň", "N",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "ō", "o", "ő", "o",
+	"Ò", "O", "Ó", "O", "Ô", "O", "Õ", "O", "Ö", "O", "Ø", "O", "Ō", "O", "Ő", "O",
+	"ř", "r", "Ř", "R",
+	"ś", "s", "š", "s", "ş", "s", "Ś", "S", "Š", "S", "Ş", "S",
+	"ť", "t", "ţ", "t", "þ", "th", "Ť", "T", "Ţ", "T", "Þ", "TH",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ū", "u", "ů", "u", "ű", "u",
+	"Ù", "U", "Ú", "U", "Û", "U", "Ü", "U", "Ū", "U", "Ů", "U", "Ű", "U",
+	"ý", "y", "ÿ", "y", "Ý", "Y", "Ÿ", "Y",
+	"ź", "z", "ż", "z", "ž", "z", "Ź", "Z", "Ż", "Z", "Ž", "Z",
+	"’", "'", "‘", "'", "“", "\"", "”", "\"", "–", "-", "—", "-", "&", " and ",
+)
+
+func FoldToASCII(text string) string {
+	return asciiFoldReplacer.Replace(text)
+}
+
+func SplitSearchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(FoldToASCII(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Lower case ASCII words of the title, used to search and match titles across sources.
+func GetMovieTitleSearchKey(title string) string {
+	return strings.Join(SplitSearchTokens(title), " ")
+}
+
+func GetMovieSortTitle(title string) string {
+	var words []string = strings.Fields(title)
+
+	if len(words) < 2 {
+		return title
+	}
+
+	var first string = strings.ToLower(words[0])
+
+	for _, article := range movieTitleArticles {
+		if first == article {
+			return strings.Join(words[1:], " ") + ", " + words[0]
+		}
+	}
+
+	return title
+}
+
+func GetMovieSlug(title string, year float64) string {
+	var tokens []string = []string{}
+
+	for _, token := range SplitSearchTokens(title) {
+		var ascii bool = true
+
+		for _, r := range token {
+			if r > unicode.MaxASCII {
+				ascii = false
+				break
+			}
+		}
+
+		if ascii {
+			tokens = append(tokens, token)
+		}
+	}
+
+	if year > 0 {
+		tokens = append(tokens, fmt.Sprintf("%.0f", year))
+	}
+
+	return strings.Join(tokens, "-")
+}
+
+// A title ending with its year set apart, "Nosferatu (1922)" or "The Matrix [1999]".
+var movieTitleBracketYearExpression *regexp.Regexp = regexp.MustCompile(`^(.*\S)\s*[(\[]((?:19|20)\d{2})[)\]]$`)
+
+// A title ending with a bare year, "Nosferatu 1922" or "Death Race 2000".
+var movieTitleTrailingYearExpression *regexp.Regexp = regexp.MustCompile(`^(.*\S)\s+((?:19|20)\d{2})$`)
+
+// Whether `title` is a release name like "Metropolis.1927.720p.BluRay" rather than
+// a title. Release names separate words with dots or underscores and carry a year,
+// resolution or source tag.
+func isReleaseNameTitle(title string, release *MovieReleaseInfo) bool {
+	if strings.Count(title, ".")+strings.Count(title, "_") <= strings.Count(title, " ") {
+		return false
+	}
+
+	return release.Year != 0 || len(release.Resolution) > 0 || len(release.Source) > 0
+}
+
+// Source titles may carry the year, "Nosferatu (1922)", or be whole release names,
+// "Metropolis.1927.720p.BluRay". Release tags are only stripped from release names,
+// "Charlotte's Web" and "The Proper Way" are titles. Titles like "Blade Runner 2049"
+// or "Death Race 2000" legitimately end with a number, a bare year is only stripped
+// when it agrees with the one the source gave.
+func GetCleanMovieTitle(title string, year float64) (string, float64) {
+	title = strings.TrimSpace(title)
+
+	release, yearIsTag := parseReleaseName(title)
+
+	if isReleaseNameTitle(title, release) {
+		if len(release.Title) < 1 {
+			return title, year
+		}
+
+		if year != 0 && release.Year != 0 && release.Year != year {
+			return title, year
+		}
+
+		if year == 0 && release.Year != 0 && !yearIsTag {
+			return title, year
+		}
+
+		if year == 0 {
+			year = release.Year
+		}
+
+		return release.Title, year
+	}
+
+	if groups := movieTitleBracketYearExpression.FindStringSubmatch(title); groups != nil {
+		bracketYear, _ := strconv.ParseFloat(groups[2], 64)
+
+		if year == 0 || year == bracketYear {
+			return groups[1], bracketYear
+		}
+	}
+
+	if groups := movieTitleTrailingYearExpression.FindStringSubmatch(title); groups != nil && year != 0 && groups[2] == strconv.FormatFloat(year, 'f', 0, 64) {
+		return groups[1], year
+	}
+
+	return title, year
+}
+
+// Fills the normalized title fields of `details`. The slug is only generated when
+// the source didn't provide one, the catalog makes it unique.
+func NormalizeMovieDetailsTitle(details *MovieDetails) {
+	if details == nil {
+		return
+	}
+
+	var title string = details.Title
+
+	if len(title) < 1 {
+		title = details.TitleEnglish
+	}
+
+	cleanTitle, year := GetCleanMovieTitle(title, details.Year)
+
+	details.CleanTitle = cleanTitle
+
+	if details.Year == 0 {
+		details.Year = year
+	}
+
+	details.SortTitle = GetMovieSortTitle(details.CleanTitle)
+	details.SearchKey = GetMovieTitleSearchKey(details.CleanTitle)
+
+	if len(details.TitleLong) < 1 && len(details.CleanTitle) > 0 && details.Year > 0 {
+		details.TitleLong = fmt.Sprintf("%s (%.0f)", details.CleanTitle, details.Year)
+	}
+
+	if len(details.Slug) < 1 {
+		details.Slug = GetMovieSlug(details.CleanTitle, details.Year)
+	}
+}
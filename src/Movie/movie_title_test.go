@@ -0,0 +1,55 @@
+package Movie
+
+import "testing"
+
+func TestGetCleanMovieTitle(t *testing.T) {
+	var tests []struct {
+		Title string
+		Year  float64
+
+		ExpectedTitle string
+		ExpectedYear  float64
+	} = []struct {
+		Title string
+		Year  float64
+
+		ExpectedTitle string
+		ExpectedYear  float64
+	}{
+		{"Nosferatu (1922)", 0, "Nosferatu", 1922},
+		{"The Matrix [1999]", 0, "The Matrix", 1999},
+		{"The Matrix (1999)", 2003, "The Matrix (1999)", 2003},
+		{"Metropolis.1927.720p.BluRay", 0, "Metropolis", 1927},
+		{"The_Movie_2019_1080p", 0, "The Movie", 2019},
+		{"Nosferatu 1922", 1922, "Nosferatu", 1922},
+
+		// Numbers ending a title stay unless the source year agrees.
+		{"Death Race 2000", 0, "Death Race 2000", 0},
+		{"Death Race 2000", 1975, "Death Race 2000", 1975},
+		{"Blade Runner 2049", 2017, "Blade Runner 2049", 2017},
+		{"Death Race 2000 (2008)", 0, "Death Race 2000", 2008},
+		{"1917", 0, "1917", 0},
+		{"1917", 2019, "1917", 2019},
+
+		// Words that are also release tags only end release names.
+		{"Charlotte's Web", 1973, "Charlotte's Web", 1973},
+		{"The Proper Way", 0, "The Proper Way", 0},
+		{"Dark Web", 2016, "Dark Web", 2016},
+		{"Cam", 2018, "Cam", 2018},
+		{"Nosferatu 1922 1080p BluRay", 0, "Nosferatu 1922 1080p BluRay", 0},
+		{"Dr. Strangelove", 1964, "Dr. Strangelove", 1964},
+		{"S.W.A.T.", 2003, "S.W.A.T.", 2003},
+
+		{"  Alien  ", 1979, "Alien", 1979},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Title, func(t *testing.T) {
+			title, year := GetCleanMovieTitle(test.Title, test.Year)
+
+			if title != test.ExpectedTitle || year != test.ExpectedYear {
+				t.Errorf("GetCleanMovieTitle(%q, %v) = %q, %v, expected %q, %v", test.Title, test.Year, title, year, test.ExpectedTitle, test.ExpectedYear)
+			}
+		})
+	}
+}
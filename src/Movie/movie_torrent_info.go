@@ -2,6 +2,9 @@ package Movie
 
 import (
 	"GServer/Config"
+	"GServer/Logger"
+	"GServer/Metrics"
+	"GServer/Tracing"
 	"bytes"
 	"context"
 	"errors"
@@ -10,9 +13,11 @@ import (
 	"math"
 	"net/http"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/anacrolix/torrent/metainfo"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type MovieTorrentFileInfo struct {
@@ -29,6 +34,8 @@ type MovieTorrentInfo struct {
 	URL    string
 	Magent string
 
+	Source string
+
 	Name string
 
 	Hash string
@@ -51,9 +58,13 @@ type MovieTorrentInfo struct {
 
 	CreatedBy string
 
+	Release *MovieReleaseInfo
+
 	Files    []*MovieTorrentFileInfo
 	MainFile *MovieTorrentFileInfo
 
+	Subtitles []*MovieTorrentSubtitleInfo
+
 	DateUploaded     string
 	DateUploadedUnix float64
 }
@@ -76,6 +87,8 @@ func NewMovieTorrentInfo() *MovieTorrentInfo {
 	torrentInfo.URL = ""
 	torrentInfo.Magent = ""
 
+	torrentInfo.Source = MOVIE_SOURCE_UNKNOWN
+
 	torrentInfo.Name = ""
 
 	torrentInfo.Hash = ""
@@ -98,9 +111,13 @@ func NewMovieTorrentInfo() *MovieTorrentInfo {
 
 	torrentInfo.CreatedBy = ""
 
+	torrentInfo.Release = nil
+
 	torrentInfo.Files = []*MovieTorrentFileInfo{}
 	torrentInfo.MainFile = nil
 
+	torrentInfo.Subtitles = []*MovieTorrentSubtitleInfo{}
+
 	torrentInfo.DateUploaded = ""
 	torrentInfo.DateUploadedUnix = 0
 
@@ -168,7 +185,33 @@ func sizeToString(targetSize float64) string {
 	return fmt.Sprintf("%0.2f Byte", float32(targetSize))
 }
 
-func ParseTorrentFromUrl(ctx context.Context, url string, torrentInfo *MovieTorrentInfo) error {
+type countingReader struct {
+	Reader io.Reader
+	Count  int64
+}
+
+func (this *countingReader) Read(data []byte) (int, error) {
+	count, err := this.Reader.Read(data)
+
+	this.Count += int64(count)
+
+	return count, err
+}
+
+func ParseTorrentFromUrl(ctx context.Context, url string, torrentInfo *MovieTorrentInfo) (err error) {
+	ctx, span := Tracing.StartSpan(ctx, "torrent.parse", attribute.String("url.full", url))
+
+	defer func() {
+		if torrentInfo != nil {
+			span.SetAttributes(
+				attribute.String(Tracing.ATTRIBUTE_SOURCE, torrentInfo.Source),
+				attribute.String(Tracing.ATTRIBUTE_INFO_HASH, torrentInfo.Hash),
+			)
+		}
+
+		Tracing.EndSpan(span, err)
+	}()
+
 	if len(url) < 1 {
 		return errors.New("Invalid URL")
 	}
@@ -183,19 +226,34 @@ func ParseTorrentFromUrl(ctx context.Context, url string, torrentInfo *MovieTorr
 		return err
 	}
 
+	var started time.Time = time.Now()
+
 	response, err := http.DefaultClient.Do(request)
 
+	Metrics.UpstreamRequestDuration.Observe(time.Since(started).Seconds(), torrentInfo.Source)
+
 	if err != nil {
+		Metrics.UpstreamRequests.Inc(torrentInfo.Source, Metrics.UPSTREAM_STATUS_ERROR)
 		return err
 	}
 
 	defer response.Body.Close()
 
+	Metrics.UpstreamRequests.Inc(torrentInfo.Source, strconv.Itoa(response.StatusCode))
+
 	if response.StatusCode != http.StatusOK {
 		return errors.New("Bad status: " + response.Status)
 	}
 
-	meta, err := metainfo.Load(io.Reader(response.Body))
+	var body *countingReader = &countingReader{Reader: response.Body, Count: 0}
+
+	defer func() {
+		Metrics.TorrentDownloadBytes.Add(float64(body.Count), torrentInfo.Source)
+
+		span.SetAttributes(attribute.Int64("gserver.torrent.download_bytes", body.Count))
+	}()
+
+	meta, err := metainfo.Load(io.Reader(body))
 
 	if err != nil {
 		return err
@@ -227,6 +285,8 @@ func ParseTorrentFromUrl(ctx context.Context, url string, torrentInfo *MovieTorr
 
 	torrentInfo.CreatedBy = meta.CreatedBy
 
+	var subtitleFiles []*MovieTorrentFileInfo = []*MovieTorrentFileInfo{}
+
 	for _, file := range info.Files {
 		var fileInfo *MovieTorrentFileInfo = NewMovieTorrentFileInfo()
 
@@ -237,13 +297,17 @@ func ParseTorrentFromUrl(ctx context.Context, url string, torrentInfo *MovieTorr
 		fileInfo.Extension = path.Ext(fileInfo.Path)
 		fileInfo.Name = fileName[0 : len(fileName)-len(fileInfo.Extension)]
 
+		fileInfo.Size = float64(file.Length)
+		fileInfo.SizeString = sizeToString(fileInfo.Size)
+
+		if Config.IsSubtitleTorrentFileExtension(fileInfo.Extension) {
+			subtitleFiles = append(subtitleFiles, fileInfo)
+		}
+
 		if !Config.IsTorrentFileExtensionValid(fileInfo.Extension) {
 			continue
 		}
 
-		fileInfo.Size = float64(file.Length)
-		fileInfo.SizeString = sizeToString(fileInfo.Size)
-
 		if torrentInfo.MainFile == nil {
 			if Config.IsMainTorrentFileExtension(fileInfo.Extension) {
 				torrentInfo.MainFile = fileInfo
@@ -253,6 +317,8 @@ func ParseTorrentFromUrl(ctx context.Context, url string, torrentInfo *MovieTorr
 		torrentInfo.Files = append(torrentInfo.Files, fileInfo)
 	}
 
+	torrentInfo.Subtitles = collectTorrentSubtitles(subtitleFiles, torrentInfo)
+
 	torrentInfo.DateUploaded = time.Unix(meta.CreationDate, 0).Format(time.DateTime)
 	torrentInfo.DateUploadedUnix = float64(meta.CreationDate)
 
@@ -260,5 +326,7 @@ func ParseTorrentFromUrl(ctx context.Context, url string, torrentInfo *MovieTorr
 		return errors.New("Couldn't find any main file in torrent file.")
 	}
 
+	Logger.DEBUG_CONTEXT(ctx, "Parsed torrent file.", "url", url, "hash", torrentInfo.Hash, "files", len(torrentInfo.Files), "bytes", body.Count)
+
 	return nil
 }
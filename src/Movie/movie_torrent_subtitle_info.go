@@ -0,0 +1,389 @@
+package Movie
+
+import (
+	"GServer/Config"
+	"path"
+	"strings"
+	"unicode"
+)
+
+const (
+	SUBTITLE_FORMAT_SUBRIP   = "SubRip"
+	SUBTITLE_FORMAT_ASS      = "ASS"
+	SUBTITLE_FORMAT_SSA      = "SSA"
+	SUBTITLE_FORMAT_WEBVTT   = "WebVTT"
+	SUBTITLE_FORMAT_VOBSUB   = "VobSub"
+	SUBTITLE_FORMAT_MICRODVD = "MicroDVD"
+	SUBTITLE_FORMAT_UNKNOWN  = "Unknown"
+
+	SUBTITLE_LANGUAGE_UNKNOWN = ""
+)
+
+type MovieTorrentSubtitleInfo struct {
+	Name      string
+	Extension string
+	Format    string
+
+	Path      string
+	IndexPath string
+
+	Language     string
+	LanguageCode string
+
+	IsForced          bool
+	IsHearingImpaired bool
+
+	SizeString string
+	Size       float64
+
+	VideoFile *MovieTorrentFileInfo
+}
+
+type subtitleLanguage struct {
+	Code string
+	Name string
+}
+
+// Language names are matched anywhere in a file or folder name, short codes only
+// where release groups put them: as the trailing token of the file name or as a
+// whole folder name. Otherwise words like "it", "no" or "dan" would match titles.
+var subtitleLanguageNames map[string]subtitleLanguage = map[string]subtitleLanguage{}
+var subtitleLanguageCodes map[string]subtitleLanguage = map[string]subtitleLanguage{}
+
+func init() {
+	var languages [][]string = [][]string{
+		{"en", "English", "english", "eng"},
+		{"es", "Spanish", "spanish", "espanol", "castellano", "spa", "esp"},
+		{"fr", "French", "french", "francais", "fre", "fra"},
+		{"de", "German", "german", "deutsch", "ger", "deu"},
+		{"it", "Italian", "italian", "italiano", "ita"},
+		{"pt", "Portuguese", "portuguese", "portugues", "por"},
+		{"pt-BR", "Brazilian Portuguese", "brazilian", "pob", "ptbr"},
+		{"ru", "Russian", "russian", "rus"},
+		{"ar", "Arabic", "arabic", "ara"},
+		{"fa", "Persian", "persian", "farsi", "per", "fas"},
+		{"tr", "Turkish", "turkish", "tur"},
+		{"nl", "Dutch", "dutch", "dut", "nld"},
+		{"pl", "Polish", "polish", "pol"},
+		{"sv", "Swedish", "swedish", "swe"},
+		{"no", "Norwegian", "norwegian", "nor", "nob"},
+		{"da", "Danish", "danish", "dan"},
+		{"fi", "Finnish", "finnish", "fin"},
+		{"el", "Greek", "greek", "gre", "ell"},
+		{"he", "Hebrew", "hebrew", "heb"},
+		{"hi", "Hindi", "hindi", "hin"},
+		{"ja", "Japanese", "japanese", "jpn", "jap"},
+		{"ko", "Korean", "korean", "kor"},
+		{"zh", "Chinese", "chinese", "chi", "zho", "chs", "cht"},
+		{"cs", "Czech", "czech", "cze", "ces"},
+		{"hu", "Hungarian", "hungarian", "hun"},
+		{"ro", "Romanian", "romanian", "rum", "ron"},
+		{"bg", "Bulgarian", "bulgarian", "bul"},
+		{"hr", "Croatian", "croatian", "hrv"},
+		{"sr", "Serbian", "serbian", "srp"},
+		{"uk", "Ukrainian", "ukrainian", "ukr"},
+		{"vi", "Vietnamese", "vietnamese", "vie"},
+		{"th", "Thai", "thai", "tha"},
+		{"id", "Indonesian", "indonesian", "ind"},
+		{"ms", "Malay", "malay", "msa"},
+	}
+
+	for _, language := range languages {
+		var info subtitleLanguage = subtitleLanguage{Code: language[0], Name: language[1]}
+
+		subtitleLanguageCodes[strings.ToLower(language[0])] = info
+
+		for _, token := range language[2:] {
+			if len(token) > 3 {
+				subtitleLanguageNames[token] = info
+			} else {
+				subtitleLanguageCodes[token] = info
+			}
+		}
+	}
+}
+
+func NewMovieTorrentSubtitleInfo() *MovieTorrentSubtitleInfo {
+	var subtitleInfo *MovieTorrentSubtitleInfo = new(MovieTorrentSubtitleInfo)
+
+	subtitleInfo.Name = ""
+	subtitleInfo.Extension = ""
+	subtitleInfo.Format = SUBTITLE_FORMAT_UNKNOWN
+
+	subtitleInfo.Path = ""
+	subtitleInfo.IndexPath = ""
+
+	subtitleInfo.Language = SUBTITLE_LANGUAGE_UNKNOWN
+	subtitleInfo.LanguageCode = SUBTITLE_LANGUAGE_UNKNOWN
+
+	subtitleInfo.IsForced = false
+	subtitleInfo.IsHearingImpaired = false
+
+	subtitleInfo.SizeString = ""
+	subtitleInfo.Size = 0
+
+	subtitleInfo.VideoFile = nil
+
+	return subtitleInfo
+}
+
+func splitSubtitleNameTokens(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func getSubtitleFormat(extension string, hasIndexFile bool) string {
+	switch strings.ToLower(extension) {
+	case ".srt":
+		return SUBTITLE_FORMAT_SUBRIP
+	case ".ass":
+		return SUBTITLE_FORMAT_ASS
+	case ".ssa":
+		return SUBTITLE_FORMAT_SSA
+	case ".vtt":
+		return SUBTITLE_FORMAT_WEBVTT
+	case ".idx":
+		return SUBTITLE_FORMAT_VOBSUB
+	case ".sub":
+		if hasIndexFile {
+			return SUBTITLE_FORMAT_VOBSUB
+		}
+
+		return SUBTITLE_FORMAT_MICRODVD
+	}
+
+	return SUBTITLE_FORMAT_UNKNOWN
+}
+
+func detectSubtitleLanguage(subtitle *MovieTorrentSubtitleInfo) {
+	var tokens []string = splitSubtitleNameTokens(subtitle.Name)
+	var languageTokens []string = []string{}
+
+	var hasHearingImpairedToken bool = false
+
+	for _, token := range tokens {
+		switch token {
+		case "forced", "foreign":
+			subtitle.IsForced = true
+		case "sdh", "cc":
+			subtitle.IsHearingImpaired = true
+		case "hi":
+			hasHearingImpairedToken = true
+			languageTokens = append(languageTokens, token)
+		default:
+			languageTokens = append(languageTokens, token)
+		}
+	}
+
+	var found *subtitleLanguage = nil
+
+	for i := len(languageTokens) - 1; i >= 0; i-- {
+		if language, exists := subtitleLanguageNames[languageTokens[i]]; exists {
+			found = &language
+			break
+		}
+	}
+
+	if found == nil && len(languageTokens) > 0 {
+		var last string = languageTokens[len(languageTokens)-1]
+		var previous string = ""
+
+		if len(languageTokens) > 1 {
+			previous = languageTokens[len(languageTokens)-2]
+		}
+
+		if language, exists := subtitleLanguageCodes[previous+last]; exists {
+			found = &language
+		} else if language, exists := subtitleLanguageNames[previous+last]; exists {
+			found = &language
+		} else if language, exists := subtitleLanguageCodes[previous]; exists && last == "hi" {
+			// "Movie.en.hi.srt" marks hearing impaired, a lone "Movie.hi.srt" is Hindi.
+			found = &language
+		} else if language, exists := subtitleLanguageCodes[last]; exists {
+			found = &language
+		}
+	}
+
+	if found == nil {
+		var directory string = path.Dir(subtitle.Path)
+
+		for directory != "." && directory != "/" && len(directory) > 0 {
+			var folderTokens []string = splitSubtitleNameTokens(path.Base(directory))
+
+			for i := len(folderTokens) - 1; i >= 0 && found == nil; i-- {
+				if language, exists := subtitleLanguageNames[folderTokens[i]]; exists {
+					found = &language
+				}
+			}
+
+			if found == nil && len(folderTokens) == 1 {
+				if language, exists := subtitleLanguageCodes[folderTokens[0]]; exists {
+					found = &language
+				}
+			}
+
+			if found != nil {
+				break
+			}
+
+			directory = path.Dir(directory)
+		}
+	}
+
+	if found == nil {
+		return
+	}
+
+	if hasHearingImpairedToken && found.Code != "hi" {
+		subtitle.IsHearingImpaired = true
+	}
+
+	subtitle.Language = found.Name
+	subtitle.LanguageCode = found.Code
+}
+
+func isPathInsideDirectory(filePath string, directory string) bool {
+	if directory == "." || len(directory) < 1 {
+		return true
+	}
+
+	return strings.HasPrefix(filePath, directory+"/")
+}
+
+func findSubtitleVideoFile(subtitle *MovieTorrentSubtitleInfo, torrentInfo *MovieTorrentInfo) *MovieTorrentFileInfo {
+	var videos []*MovieTorrentFileInfo = []*MovieTorrentFileInfo{}
+
+	for _, file := range torrentInfo.Files {
+		if Config.IsSubtitleTorrentFileExtension(file.Extension) {
+			continue
+		}
+
+		if Config.IsMainTorrentFileExtension(file.Extension) {
+			videos = append(videos, file)
+		}
+	}
+
+	if len(videos) < 1 {
+		return torrentInfo.MainFile
+	}
+
+	var subtitleName string = strings.ToLower(subtitle.Name)
+
+	var best *MovieTorrentFileInfo = nil
+
+	for _, video := range videos {
+		var videoName string = strings.ToLower(video.Name)
+
+		if len(videoName) < 1 || !strings.HasPrefix(subtitleName, videoName) {
+			continue
+		}
+
+		if best == nil || len(video.Name) > len(best.Name) {
+			best = video
+		}
+	}
+
+	if best != nil {
+		return best
+	}
+
+	// Packs often keep subtitles under "Subs/<Video Name>/2_English.srt".
+	for _, folder := range strings.Split(path.Dir(subtitle.Path), "/") {
+		var folderName string = strings.ToLower(folder)
+
+		for _, video := range videos {
+			if strings.ToLower(video.Name) == folderName {
+				return video
+			}
+		}
+	}
+
+	var directory string = path.Dir(subtitle.Path)
+
+	for {
+		var candidates []*MovieTorrentFileInfo = []*MovieTorrentFileInfo{}
+
+		for _, video := range videos {
+			if isPathInsideDirectory(video.Path, directory) {
+				candidates = append(candidates, video)
+			}
+		}
+
+		if len(candidates) == 1 {
+			return candidates[0]
+		}
+
+		if len(candidates) > 1 {
+			break
+		}
+
+		if directory == "." || directory == "/" || len(directory) < 1 {
+			break
+		}
+
+		directory = path.Dir(directory)
+	}
+
+	return torrentInfo.MainFile
+}
+
+func collectTorrentSubtitles(subtitleFiles []*MovieTorrentFileInfo, torrentInfo *MovieTorrentInfo) []*MovieTorrentSubtitleInfo {
+	var subtitles []*MovieTorrentSubtitleInfo = []*MovieTorrentSubtitleInfo{}
+
+	var indexFiles map[string]*MovieTorrentFileInfo = map[string]*MovieTorrentFileInfo{}
+	var subFiles map[string]*MovieTorrentFileInfo = map[string]*MovieTorrentFileInfo{}
+
+	for _, file := range subtitleFiles {
+		var key string = strings.ToLower(strings.TrimSuffix(file.Path, file.Extension))
+
+		switch strings.ToLower(file.Extension) {
+		case ".idx":
+			indexFiles[key] = file
+		case ".sub":
+			subFiles[key] = file
+		}
+	}
+
+	for _, file := range subtitleFiles {
+		var key string = strings.ToLower(strings.TrimSuffix(file.Path, file.Extension))
+		var extension string = strings.ToLower(file.Extension)
+
+		var indexFile *MovieTorrentFileInfo = nil
+
+		switch extension {
+		case ".idx":
+			// VobSub pairs are reported once, through their .sub data file.
+			if _, exists := subFiles[key]; exists {
+				continue
+			}
+		case ".sub":
+			indexFile = indexFiles[key]
+		}
+
+		var subtitle *MovieTorrentSubtitleInfo = NewMovieTorrentSubtitleInfo()
+
+		subtitle.Name = file.Name
+		subtitle.Extension = file.Extension
+		subtitle.Format = getSubtitleFormat(extension, indexFile != nil)
+
+		subtitle.Path = file.Path
+
+		subtitle.Size = file.Size
+
+		if indexFile != nil {
+			subtitle.IndexPath = indexFile.Path
+			subtitle.Size += indexFile.Size
+		}
+
+		subtitle.SizeString = sizeToString(subtitle.Size)
+
+		detectSubtitleLanguage(subtitle)
+
+		subtitle.VideoFile = findSubtitleVideoFile(subtitle, torrentInfo)
+
+		subtitles = append(subtitles, subtitle)
+	}
+
+	return subtitles
+}
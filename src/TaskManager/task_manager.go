@@ -3,6 +3,8 @@ package TaskManager
 import (
 	"GServer/Logger"
 	"context"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 	"unsafe"
@@ -31,6 +33,11 @@ type Task struct {
 
 	Delay time.Duration
 
+	// Context the task was added with, the manager's context unless it was added
+	// with AddTaskWithContext. Carries values like the correlation id of the request
+	// or crawl page that queued the task.
+	Context context.Context
+
 	Manager *TaskManager
 
 	SafeLoops map[uintptr]*TaskSafeLoop
@@ -49,6 +56,18 @@ type TaskSafeLoop struct {
 	AttachedTo *Task
 }
 
+// Snapshot of a task manager's queue, used to report backlog.
+type TaskManagerStatus struct {
+	Name string
+
+	PendingTasks int
+	RunningTasks int
+
+	MaximumThreads int
+
+	Paused bool
+}
+
 type TaskManager struct {
 	Name string
 
@@ -260,7 +279,7 @@ func (this *TaskManager) Add(xA ...int) {
 	this.TaskCount += x
 }
 
-func (this *TaskManager) AddTask(callback TaskCallback) *Task {
+func (this *TaskManager) addTask(ctx context.Context, callback TaskCallback, delay time.Duration) *Task {
 	this.mutex.Lock()
 
 	var lastTaskCount int = len(this.Tasks)
@@ -274,7 +293,13 @@ func (this *TaskManager) AddTask(callback TaskCallback) *Task {
 	task.Finished = false
 	task.Joined = false
 
-	task.Delay = DISABLED_TASK_DELAY
+	task.Delay = delay
+
+	task.Context = ctx
+
+	if task.Context == nil {
+		task.Context = this.Context
+	}
 
 	task.Manager = this
 
@@ -298,12 +323,22 @@ func (this *TaskManager) AddTask(callback TaskCallback) *Task {
 	return task
 }
 
+func (this *TaskManager) AddTask(callback TaskCallback) *Task {
+	return this.addTask(nil, callback, DISABLED_TASK_DELAY)
+}
+
 func (this *TaskManager) AddTaskWithDelay(task TaskCallback, delay time.Duration) *Task {
-	var t *Task = this.AddTask(task)
+	return this.addTask(nil, task, delay)
+}
 
-	t.Delay = delay
+// Adds a task whose Context is `ctx` instead of the manager's, so it keeps the
+// caller's values and cancellation.
+func (this *TaskManager) AddTaskWithContext(ctx context.Context, callback TaskCallback) *Task {
+	return this.addTask(ctx, callback, DISABLED_TASK_DELAY)
+}
 
-	return t
+func (this *TaskManager) AddTaskWithContextAndDelay(ctx context.Context, callback TaskCallback, delay time.Duration) *Task {
+	return this.addTask(ctx, callback, delay)
 }
 
 func (this *TaskManager) resumeProcessorThread() {
@@ -529,6 +564,83 @@ func (this *TaskManager) Join() {
 	safeWaitForChannel(this, this.joinChannel, func() bool { return this.waitingForJoinChannel })
 }
 
+// Changes the thread limit, queued tasks start right away when it's raised.
+func (this *TaskManager) SetMaximumThreads(maximumThreads int) {
+	this.mutex.Lock()
+
+	this.MaxmiumThreads = maximumThreads
+
+	if this.waitingForDoneTaskChannel {
+		this.doneTaskChannel <- true
+		this.waitingForDoneTaskChannel = false
+	}
+
+	this.mutex.Unlock()
+}
+
+func (this *TaskManager) GetStatus() *TaskManagerStatus {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	var status *TaskManagerStatus = new(TaskManagerStatus)
+
+	status.Name = this.Name
+
+	status.PendingTasks = len(this.Tasks)
+	status.RunningTasks = len(this.StartedTasks)
+
+	status.MaximumThreads = this.MaxmiumThreads
+
+	status.Paused = this.Paused
+
+	return status
+}
+
+// Returns the status of every task manager, sorted by name.
+func GetTaskManagerStatuses() []*TaskManagerStatus {
+	globalTasksMutex.Lock()
+
+	var taskManagers []*TaskManager = []*TaskManager{}
+
+	for _, taskManager := range Tasks {
+		taskManagers = append(taskManagers, taskManager)
+	}
+
+	globalTasksMutex.Unlock()
+
+	var statuses []*TaskManagerStatus = []*TaskManagerStatus{}
+
+	for _, taskManager := range taskManagers {
+		statuses = append(statuses, taskManager.GetStatus())
+	}
+
+	sort.Slice(statuses, func(i int, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// Sets the thread limit of the task manager called `name` and of the ones named
+// "<name>_<id>", like the parsers created for every crawled page.
+func SetMaximumThreads(name string, maximumThreads int) {
+	globalTasksMutex.Lock()
+
+	var taskManagers []*TaskManager = []*TaskManager{}
+
+	for taskName, taskManager := range Tasks {
+		if taskName == name || strings.HasPrefix(taskName, name+"_") {
+			taskManagers = append(taskManagers, taskManager)
+		}
+	}
+
+	globalTasksMutex.Unlock()
+
+	for _, taskManager := range taskManagers {
+		taskManager.SetMaximumThreads(maximumThreads)
+	}
+}
+
 func ExistsTaskManager(name string) bool {
 	globalTasksMutex.Lock()
 
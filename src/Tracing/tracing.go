@@ -0,0 +1,203 @@
+package Tracing
+
+import (
+	"GServer/Config"
+	"GServer/Defaults"
+	"GServer/Logger"
+	"context"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	TRACER_NAME = "GServer"
+
+	EXPORTER_OTLP = "otlp"
+	EXPORTER_FILE = "file"
+
+	ATTRIBUTE_CORRELATION_ID = "gserver.correlation_id"
+	ATTRIBUTE_SOURCE         = "gserver.source"
+	ATTRIBUTE_CRAWLER        = "gserver.crawler"
+	ATTRIBUTE_PAGE           = "gserver.page"
+	ATTRIBUTE_MOVIE_ID       = "gserver.movie.id"
+	ATTRIBUTE_MOVIE_TITLE    = "gserver.movie.title"
+	ATTRIBUTE_MOVIE_SLUG     = "gserver.movie.slug"
+	ATTRIBUTE_INFO_HASH      = "gserver.torrent.info_hash"
+)
+
+var Settings Config.ConfigTracing = Config.ConfigTracing{}
+
+// Spans go nowhere until Initialize sets up an exporter.
+var Tracer trace.Tracer = noop.NewTracerProvider().Tracer(TRACER_NAME)
+
+var provider *sdktrace.TracerProvider = nil
+
+// Written by the file exporter, closed once the provider flushed its spans.
+var file *Logger.RotatingFile = nil
+
+func loadSettings() {
+	Settings = Config.Get().Tracing
+
+	if len(Settings.Exporter) < 1 {
+		Settings.Exporter = Defaults.TRACING_EXPORTER
+	}
+
+	if len(Settings.ServiceName) < 1 {
+		Settings.ServiceName = Defaults.TRACING_SERVICE_NAME
+	}
+
+	if len(Settings.OtlpEndpoint) < 1 {
+		Settings.OtlpEndpoint = Defaults.TRACING_OTLP_ENDPOINT
+	}
+
+	if len(Settings.File) < 1 {
+		Settings.File = Defaults.TRACING_FILE_NAME
+	}
+
+	if Settings.FileMaxSize == 0 {
+		Settings.FileMaxSize = Defaults.TRACING_FILE_MAX_SIZE
+	}
+
+	if Settings.FileRotationInterval == 0 {
+		Settings.FileRotationInterval = Defaults.TRACING_FILE_ROTATION_INTERVAL
+	}
+
+	if Settings.FileMaxBackups == 0 {
+		Settings.FileMaxBackups = Defaults.TRACING_FILE_MAX_BACKUPS
+	}
+
+	if Settings.FileMaxBackupAge == 0 {
+		Settings.FileMaxBackupAge = Defaults.TRACING_FILE_MAX_BACKUP_AGE
+	}
+
+	if Settings.SampleRatio <= 0 || Settings.SampleRatio > 1 {
+		Settings.SampleRatio = Defaults.TRACING_SAMPLE_RATIO
+	}
+}
+
+func newExporter() (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(Settings.Exporter) {
+	case EXPORTER_OTLP:
+		return otlptracehttp.New(
+			context.Background(),
+			otlptracehttp.WithEndpointURL(Settings.OtlpEndpoint),
+			otlptracehttp.WithHeaders(Settings.OtlpHeaders),
+		)
+	case EXPORTER_FILE:
+		filePath, err := Config.GetApplicationFilePath(Settings.File)
+
+		if err != nil {
+			return nil, err
+		}
+
+		file = Logger.NewRotatingFile(
+			filePath,
+			Settings.FileMaxSize,
+			Settings.FileRotationInterval,
+			Settings.FileMaxBackups,
+			Settings.FileMaxBackupAge,
+			Settings.FileCompress,
+		)
+
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	}
+
+	return nil, errors.New("Unknown trace exporter '" + Settings.Exporter + "'")
+}
+
+// Starts a span named `name` as a child of the span in `ctx`. The correlation id
+// carried by `ctx` is added so traces and log lines can be matched.
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if id := Logger.GetCorrelationId(ctx); len(id) > 0 {
+		attributes = append(attributes, attribute.String(ATTRIBUTE_CORRELATION_ID, id))
+	}
+
+	return Tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// Ends `span`, marking it failed when `err` is set.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func closeFile() {
+	if file == nil {
+		return
+	}
+
+	if err := file.Close(); err != nil {
+		Logger.WARN("Couldn't close trace file.", "path", file.Path, "error", err)
+	}
+
+	file = nil
+}
+
+func Initialize() {
+	Logger.INFO("Initializing tracing...")
+
+	loadSettings()
+
+	if !Settings.Enabled {
+		Logger.INFO("Tracing initialized.", "enabled", false)
+		return
+	}
+
+	exporter, err := newExporter()
+
+	if err != nil {
+		Logger.ERROR("Couldn't create trace exporter, tracing is off.", "exporter", Settings.Exporter, "error", err)
+
+		closeFile()
+		return
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", Settings.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(Settings.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	Tracer = provider.Tracer(TRACER_NAME)
+
+	Logger.INFO("Tracing initialized.", "enabled", true, "exporter", Settings.Exporter, "sample_ratio", Settings.SampleRatio)
+}
+
+func Uninitialize() {
+	Logger.INFO("Uninitializing tracing...")
+
+	if provider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), Defaults.TRACING_SHUTDOWN_TIMEOUT)
+
+		// Flushes spans still waiting in the batcher.
+		if err := provider.Shutdown(ctx); err != nil {
+			Logger.WARN("Couldn't flush pending spans.", "error", err)
+		}
+
+		cancel()
+
+		provider = nil
+	}
+
+	closeFile()
+
+	Tracer = noop.NewTracerProvider().Tracer(TRACER_NAME)
+
+	Logger.INFO("Tracing uninitialized.")
+}
@@ -0,0 +1,156 @@
+package Webhooks
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DELIVERY_STATUS_PENDING   = "pending"
+	DELIVERY_STATUS_SUCCEEDED = "succeeded"
+	DELIVERY_STATUS_FAILED    = "failed"
+)
+
+type DeliveryAttempt struct {
+	Time time.Time
+
+	StatusCode int
+	Error      string
+
+	Duration time.Duration
+}
+
+type Delivery struct {
+	Id string
+
+	URL string
+
+	EventId   uint64
+	EventType string
+
+	Status string
+
+	Attempts []*DeliveryAttempt
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	NextAttemptAt time.Time
+}
+
+type DeliveryQuery struct {
+	Status    string
+	EventType string
+	URL       string
+
+	Limit int
+}
+
+var deliveries []*Delivery = nil
+var deliveryLogSize int = 0
+
+var deliveryMutex sync.RWMutex
+
+func NewDeliveryQuery() *DeliveryQuery {
+	var query *DeliveryQuery = new(DeliveryQuery)
+
+	query.Status = ""
+	query.EventType = ""
+	query.URL = ""
+
+	query.Limit = 0
+
+	return query
+}
+
+func copyDelivery(delivery *Delivery) *Delivery {
+	var copied *Delivery = new(Delivery)
+
+	*copied = *delivery
+
+	copied.Attempts = []*DeliveryAttempt{}
+
+	for _, attempt := range delivery.Attempts {
+		var a DeliveryAttempt = *attempt
+
+		copied.Attempts = append(copied.Attempts, &a)
+	}
+
+	return copied
+}
+
+func logDelivery(delivery *Delivery) {
+	deliveryMutex.Lock()
+	defer deliveryMutex.Unlock()
+
+	deliveries = append(deliveries, delivery)
+
+	if deliveryLogSize > 0 && len(deliveries) > deliveryLogSize {
+		deliveries = deliveries[len(deliveries)-deliveryLogSize:]
+	}
+}
+
+func updateDelivery(delivery *Delivery, update func(*Delivery)) {
+	deliveryMutex.Lock()
+	defer deliveryMutex.Unlock()
+
+	update(delivery)
+
+	delivery.UpdatedAt = time.Now()
+}
+
+func (this *DeliveryQuery) Matches(delivery *Delivery) bool {
+	if len(this.Status) > 0 && !strings.EqualFold(delivery.Status, this.Status) {
+		return false
+	}
+
+	if len(this.EventType) > 0 && !strings.EqualFold(delivery.EventType, this.EventType) {
+		return false
+	}
+
+	if len(this.URL) > 0 && delivery.URL != this.URL {
+		return false
+	}
+
+	return true
+}
+
+// Returns logged deliveries matching `query`, most recent first.
+func GetDeliveries(query *DeliveryQuery) []*Delivery {
+	if query == nil {
+		query = NewDeliveryQuery()
+	}
+
+	deliveryMutex.RLock()
+	defer deliveryMutex.RUnlock()
+
+	var result []*Delivery = []*Delivery{}
+
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		if !query.Matches(deliveries[i]) {
+			continue
+		}
+
+		result = append(result, copyDelivery(deliveries[i]))
+
+		if query.Limit > 0 && len(result) >= query.Limit {
+			break
+		}
+	}
+
+	return result
+}
+
+func GetDelivery(id string) *Delivery {
+	deliveryMutex.RLock()
+	defer deliveryMutex.RUnlock()
+
+	for _, delivery := range deliveries {
+		if delivery.Id == id {
+			return copyDelivery(delivery)
+		}
+	}
+
+	return nil
+}
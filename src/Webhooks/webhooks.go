@@ -0,0 +1,398 @@
+package Webhooks
+
+import (
+	"GServer/Catalog"
+	"GServer/Config"
+	"GServer/Defaults"
+	"GServer/Events"
+	"GServer/Logger"
+	"GServer/Movie"
+	"GServer/TaskManager"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	HTTP "net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	WEBHOOK_SIGNATURE_HEADER = "X-GServer-Signature"
+	WEBHOOK_EVENT_HEADER     = "X-GServer-Event"
+	WEBHOOK_DELIVERY_HEADER  = "X-GServer-Delivery"
+
+	WEBHOOK_USER_AGENT = "GServer-Webhooks"
+)
+
+// Delivered to targets that don't list their events.
+var DefaultWebhookEvents []string = []string{
+	Events.EVENT_TYPE_MOVIE_ADDED,
+	Events.EVENT_TYPE_TORRENT_ADDED,
+	Events.EVENT_TYPE_CRAWLER_FINISHED,
+	Events.EVENT_TYPE_CRAWLER_FAILED,
+}
+
+type WebhookTarget struct {
+	URL    string
+	Secret string
+
+	Events map[string]bool
+}
+
+// Body of webhook requests, movie events carry the stored movie.
+type WebhookPayload struct {
+	Id   uint64
+	Type string
+	Time time.Time
+
+	Source string
+
+	Data Events.EventData
+
+	Movie *Movie.MovieDetails
+}
+
+var Targets []*WebhookTarget = nil
+
+var Settings Config.ConfigWebhooks = Config.ConfigWebhooks{}
+
+var Tasks *TaskManager.TaskManager = nil
+var DispatcherTasks *TaskManager.TaskManager = nil
+
+var Context context.Context = nil
+var ContextCancel context.CancelFunc = nil
+
+var httpClient *HTTP.Client = nil
+var subscription *Events.Subscription = nil
+
+func newDeliveryId() string {
+	var data []byte = make([]byte, 16)
+
+	if _, err := rand.Read(data); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(data)
+}
+
+func GetPayloadSignature(secret string, body []byte) string {
+	var mac hash.Hash = hmac.New(sha256.New, []byte(secret))
+
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func getRetryBackoff(attempt int) time.Duration {
+	var backoff time.Duration = Settings.RetryBackoff
+
+	for i := 1; i < attempt && backoff < Settings.MaximumRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, Settings.MaximumRetryBackoff)
+}
+
+func isRetryableStatusCode(statusCode int) bool {
+	return statusCode >= 500 || statusCode == HTTP.StatusTooManyRequests || statusCode == HTTP.StatusRequestTimeout
+}
+
+func newWebhookPayload(event *Events.Event) *WebhookPayload {
+	var payload *WebhookPayload = new(WebhookPayload)
+
+	payload.Id = event.Id
+	payload.Type = event.Type
+	payload.Time = event.Time
+
+	payload.Source = event.Source
+
+	payload.Data = event.Data
+
+	payload.Movie = nil
+
+	slug, ok := event.Data["Slug"].(string)
+
+	if !ok {
+		slug, ok = event.Data["MovieSlug"].(string)
+	}
+
+	if ok && len(slug) > 0 {
+		payload.Movie = Catalog.GetMovieBySlug(slug)
+	}
+
+	return payload
+}
+
+func sendDeliveryAttempt(target *WebhookTarget, delivery *Delivery, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(Context, Settings.RequestTimeout)
+	defer cancel()
+
+	request, err := HTTP.NewRequestWithContext(ctx, HTTP.MethodPost, target.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	request.Header.Set("User-Agent", WEBHOOK_USER_AGENT)
+
+	request.Header.Set(WEBHOOK_EVENT_HEADER, delivery.EventType)
+	request.Header.Set(WEBHOOK_DELIVERY_HEADER, delivery.Id)
+
+	if len(target.Secret) > 0 {
+		request.Header.Set(WEBHOOK_SIGNATURE_HEADER, GetPayloadSignature(target.Secret, body))
+	}
+
+	response, err := httpClient.Do(request)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("Target responded with status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+// Posts `body` to the target until it answers with a 2xx status, waiting longer
+// after every failed attempt. Client errors other than 408 and 429 aren't retried.
+func deliver(target *WebhookTarget, delivery *Delivery, body []byte) {
+	for attempt := 1; attempt <= Settings.MaxAttempts && Context.Err() == nil; attempt++ {
+		var started time.Time = time.Now()
+
+		statusCode, err := sendDeliveryAttempt(target, delivery, body)
+
+		var record *DeliveryAttempt = new(DeliveryAttempt)
+
+		record.Time = started
+		record.StatusCode = statusCode
+		record.Error = ""
+		record.Duration = time.Since(started)
+
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		var retry bool = err != nil && (statusCode == 0 || isRetryableStatusCode(statusCode)) && attempt < Settings.MaxAttempts
+		var backoff time.Duration = getRetryBackoff(attempt)
+
+		updateDelivery(delivery, func(d *Delivery) {
+			d.Attempts = append(d.Attempts, record)
+
+			if err == nil {
+				d.Status = DELIVERY_STATUS_SUCCEEDED
+			} else if !retry {
+				d.Status = DELIVERY_STATUS_FAILED
+			} else {
+				d.NextAttemptAt = time.Now().Add(backoff)
+			}
+		})
+
+		if err == nil {
+			return
+		}
+
+		if !retry {
+			Logger.WARN("Webhook delivery failed.", "url", target.URL, "event", delivery.EventType, "attempts", attempt, "error", err)
+			return
+		}
+
+		select {
+		case <-Context.Done():
+		case <-time.After(backoff):
+		}
+	}
+
+	updateDelivery(delivery, func(d *Delivery) {
+		if d.Status == DELIVERY_STATUS_PENDING {
+			d.Status = DELIVERY_STATUS_FAILED
+		}
+	})
+}
+
+func dispatch(event *Events.Event) {
+	var payload *WebhookPayload = nil
+	var body []byte = nil
+
+	for _, target := range Targets {
+		if !target.Events[event.Type] {
+			continue
+		}
+
+		if payload == nil {
+			payload = newWebhookPayload(event)
+
+			data, err := json.Marshal(payload)
+
+			if err != nil {
+				Logger.ERROR("Couldn't encode webhook payload.", "event", event.Type, "error", err)
+				return
+			}
+
+			body = data
+		}
+
+		var delivery *Delivery = new(Delivery)
+
+		delivery.Id = newDeliveryId()
+
+		delivery.URL = target.URL
+
+		delivery.EventId = event.Id
+		delivery.EventType = event.Type
+
+		delivery.Status = DELIVERY_STATUS_PENDING
+
+		delivery.Attempts = []*DeliveryAttempt{}
+
+		delivery.CreatedAt = time.Now()
+		delivery.UpdatedAt = delivery.CreatedAt
+
+		delivery.NextAttemptAt = delivery.CreatedAt
+
+		logDelivery(delivery)
+
+		var t *WebhookTarget = target
+
+		Tasks.AddTask(func(task *TaskManager.Task) {
+			deliver(t, delivery, body)
+		})
+	}
+}
+
+func loadSettings() {
+	Settings = Config.Get().Webhooks
+
+	if Settings.MaxThreads < 1 {
+		Settings.MaxThreads = Defaults.TASKS_MAX_THREADS_WEBHOOKS
+	}
+
+	if Settings.MaxAttempts < 1 {
+		Settings.MaxAttempts = Defaults.WEBHOOKS_MAX_ATTEMPTS
+	}
+
+	if Settings.RetryBackoff <= 0 {
+		Settings.RetryBackoff = Defaults.WEBHOOKS_RETRY_BACKOFF
+	}
+
+	if Settings.MaximumRetryBackoff <= 0 {
+		Settings.MaximumRetryBackoff = Defaults.WEBHOOKS_MAXIMUM_RETRY_BACKOFF
+	}
+
+	if Settings.RequestTimeout <= 0 {
+		Settings.RequestTimeout = Defaults.WEBHOOKS_REQUEST_TIMEOUT
+	}
+
+	if Settings.DeliveryLogSize < 1 {
+		Settings.DeliveryLogSize = Defaults.WEBHOOKS_DELIVERY_LOG_SIZE
+	}
+
+	Targets = []*WebhookTarget{}
+
+	for _, configTarget := range Settings.Targets {
+		if configTarget.Disabled {
+			continue
+		}
+
+		if len(configTarget.URL) < 1 {
+			Logger.WARN("Ignoring webhook target without url.")
+			continue
+		}
+
+		var target *WebhookTarget = new(WebhookTarget)
+
+		target.URL = configTarget.URL
+		target.Secret = configTarget.Secret
+
+		var events []string = configTarget.Events
+
+		if len(events) < 1 {
+			events = DefaultWebhookEvents
+		}
+
+		target.Events = map[string]bool{}
+
+		for _, eventType := range events {
+			for name := range Events.ParseEventTypes(eventType) {
+				target.Events[name] = true
+			}
+		}
+
+		Targets = append(Targets, target)
+	}
+}
+
+func Initialize() {
+	Logger.INFO("Initializing webhooks...")
+
+	loadSettings()
+
+	deliveryMutex.Lock()
+
+	deliveries = []*Delivery{}
+	deliveryLogSize = Settings.DeliveryLogSize
+
+	deliveryMutex.Unlock()
+
+	Context, ContextCancel = context.WithCancel(TaskManager.MainContext)
+
+	httpClient = &HTTP.Client{}
+
+	Tasks = TaskManager.CreateTaskManagerWithContext(Context, "WEBHOOKS", Settings.MaxThreads)
+	DispatcherTasks = TaskManager.CreateTaskManagerWithContext(Context, "WEBHOOKS_DISPATCHER", TaskManager.UNLIMITED_THREAD_COUNT)
+
+	var types map[string]bool = map[string]bool{}
+
+	for _, target := range Targets {
+		for eventType := range target.Events {
+			types[eventType] = true
+		}
+	}
+
+	if len(types) > 0 {
+		subscription = Events.SubscribeQueued(types, 0)
+	}
+
+	if subscription != nil {
+		var events chan *Events.Event = subscription.Channel
+
+		DispatcherTasks.AddTask(func(task *TaskManager.Task) {
+			for event := range events {
+				dispatch(event)
+			}
+		})
+	}
+
+	Tasks.Start()
+	DispatcherTasks.Start()
+
+	Logger.INFO("Webhooks initialized.", "targets", len(Targets))
+}
+
+func Uninitialize() {
+	Logger.INFO("Uninitializing webhooks...")
+
+	Events.Unsubscribe(subscription)
+
+	subscription = nil
+
+	ContextCancel()
+
+	TaskManager.DeleteTaskManager(DispatcherTasks.Name)
+	TaskManager.DeleteTaskManager(Tasks.Name)
+
+	Logger.INFO("Webhooks uninitialized.")
+}
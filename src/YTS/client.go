@@ -5,9 +5,12 @@ package YTS
 import (
 	"GServer/Config"
 	"GServer/Defaults"
+	"GServer/Events"
 	"GServer/Logger"
+	"GServer/Metrics"
 	"GServer/Movie"
 	"GServer/TaskManager"
+	"GServer/Tracing"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -16,9 +19,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 	"unsafe"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type JsonDictionary map[string]any
@@ -36,14 +43,24 @@ type Client struct {
 	HttpClient *http.Client
 }
 
-func (this *Client) fetch(url *url.URL, method string, payload []byte) (JsonDictionary, error) {
+func (this *Client) fetch(ctx context.Context, url *url.URL, method string, payload []byte) (_ JsonDictionary, err error) {
+	ctx, span := Tracing.StartSpan(ctx, "upstream.fetch",
+		attribute.String(Tracing.ATTRIBUTE_SOURCE, Movie.MOVIE_SOURCE_YTS),
+		attribute.String("http.request.method", method),
+		attribute.String("url.full", url.String()),
+	)
+
+	defer func() {
+		Tracing.EndSpan(span, err)
+	}()
+
 	var buffer *bytes.Buffer = bytes.NewBufferString("")
 
 	if payload != nil {
 		buffer = bytes.NewBuffer(payload)
 	}
 
-	requestContext, requestContextCancel := context.WithTimeout(this.Context, this.Timeout)
+	requestContext, requestContextCancel := context.WithTimeout(ctx, this.Timeout)
 
 	defer requestContextCancel()
 
@@ -53,12 +70,21 @@ func (this *Client) fetch(url *url.URL, method string, payload []byte) (JsonDict
 		return nil, err
 	}
 
+	var started time.Time = time.Now()
+
 	response, err := this.HttpClient.Do(request)
 
+	Metrics.UpstreamRequestDuration.Observe(time.Since(started).Seconds(), Movie.MOVIE_SOURCE_YTS)
+
 	if err != nil {
+		Metrics.UpstreamRequests.Inc(Movie.MOVIE_SOURCE_YTS, Metrics.UPSTREAM_STATUS_ERROR)
 		return nil, err
 	}
 
+	Metrics.UpstreamRequests.Inc(Movie.MOVIE_SOURCE_YTS, strconv.Itoa(response.StatusCode))
+
+	span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+
 	defer response.Body.Close()
 
 	bodyBytes, err := io.ReadAll(response.Body)
@@ -134,7 +160,22 @@ func setMovieDetail[T any](detail *T, jsonData *map[string]interface{}, field st
 	*detail = value
 }
 
-func parseMovieDetailsFromJsonData(client *Client, details *Movie.MovieDetails, jsonData *map[string]interface{}) {
+func parseMovieDetailsFromJsonData(ctx context.Context, client *Client, details *Movie.MovieDetails, jsonData *map[string]interface{}) {
+	ctx, span := Tracing.StartSpan(ctx, "movie.parse", attribute.String(Tracing.ATTRIBUTE_SOURCE, Movie.MOVIE_SOURCE_YTS))
+
+	defer func() {
+		span.SetAttributes(
+			attribute.Float64(Tracing.ATTRIBUTE_MOVIE_ID, details.Id),
+			attribute.String(Tracing.ATTRIBUTE_MOVIE_TITLE, details.Title),
+			attribute.Int("gserver.movie.torrent_count", len(details.Torrents)),
+		)
+
+		Tracing.EndSpan(span, nil)
+	}()
+
+	details.Source = Movie.MOVIE_SOURCE_YTS
+	details.Sources = []string{Movie.MOVIE_SOURCE_YTS}
+
 	setMovieDetail(&details.Id, jsonData, "id")
 
 	setMovieDetail(&details.URL, jsonData, "url")
@@ -168,6 +209,33 @@ func parseMovieDetailsFromJsonData(client *Client, details *Movie.MovieDetails,
 		}
 	}
 
+	castData, exists := (*jsonData)["cast"]
+
+	if exists {
+		castList, ok := castData.([]interface{})
+
+		if ok {
+			var cast []*Movie.MovieCastInfo
+
+			for _, c := range castList {
+				if castInfo, ok := c.(map[string]interface{}); ok {
+					var member *Movie.MovieCastInfo = Movie.NewMovieCastInfo()
+
+					setMovieDetail(&member.Name, &castInfo, "name")
+					setMovieDetail(&member.CharacterName, &castInfo, "character_name")
+
+					setMovieDetail(&member.IMDBCode, &castInfo, "imdb_code")
+
+					setMovieDetail(&member.SmallImage, &castInfo, "url_small_image")
+
+					cast = append(cast, member)
+				}
+			}
+
+			details.Cast = cast
+		}
+	}
+
 	setMovieDetail(&details.LikeCount, jsonData, "like_count")
 
 	setMovieDetail(&details.Summery, jsonData, "summary")
@@ -199,16 +267,35 @@ func parseMovieDetailsFromJsonData(client *Client, details *Movie.MovieDetails,
 
 			var appendListMutex sync.Mutex
 
-			tmContext, _ := context.WithTimeout(client.Context, client.Timeout)
+			tmContext, tmContextCancel := context.WithTimeout(ctx, client.Timeout)
+
+			defer tmContextCancel()
 
-			var taskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(tmContext, "YTS_TORRENT_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(details))), Config.Main.TasksMaxThreads.YTS_TORRENT_PARSER)
+			var taskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(tmContext, "YTS_TORRENT_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(details))), Config.Get().TasksMaxThreads.YTS_TORRENT_PARSER)
 
 			taskManager.Start()
 
+			var torrentHashes map[string]bool = map[string]bool{}
+
 			for _, t := range torrentsList {
 				if torrentInfo, ok := t.(map[string]interface{}); ok {
+					var hash string = ""
+
+					setMovieDetail(&hash, &torrentInfo, "hash")
+
+					// YTS sometimes lists the same torrent twice for a movie.
+					if len(hash) > 0 {
+						if torrentHashes[strings.ToLower(hash)] {
+							continue
+						}
+
+						torrentHashes[strings.ToLower(hash)] = true
+					}
+
 					var torrent *Movie.MovieTorrentInfo = Movie.NewMovieTorrentInfo()
 
+					torrent.Source = Movie.MOVIE_SOURCE_YTS
+
 					setMovieDetail(&torrent.URL, &torrentInfo, "url")
 
 					setMovieDetail(&torrent.Hash, &torrentInfo, "hash")
@@ -233,17 +320,34 @@ func parseMovieDetailsFromJsonData(client *Client, details *Movie.MovieDetails,
 					setMovieDetail(&torrent.DateUploadedUnix, &torrentInfo, "date_uploaded_unix")
 
 					taskManager.AddTaskWithDelay(func(t *TaskManager.Task) {
-						err := Movie.ParseTorrentFromUrl(tmContext, torrent.URL, torrent)
+						err := Movie.ParseTorrentFromUrl(t.Context, torrent.URL, torrent)
 
 						if err != nil {
-							Logger.WARN("Failed to parse torrent file. [URL: " + torrent.URL + ", Message: " + err.Error() + "]")
-							return
+							Logger.WARN_CONTEXT(t.Context, "Failed to parse torrent file.", "url", torrent.URL, "title", details.Title, "error", err)
+
+							Events.Publish(Events.EVENT_TYPE_TORRENT_PARSE_FAILED, Movie.MOVIE_SOURCE_YTS, Events.EventData{
+								"Title": details.Title,
+								"URL":   torrent.URL,
+								"Error": err.Error(),
+							})
+
+							Metrics.TorrentsParseFailures.Inc(Movie.MOVIE_SOURCE_YTS)
+
+							// The torrent stays listed with the API's fields, named the way YTS names its releases.
+							if len(torrent.Name) < 1 {
+								torrent.Name = fmt.Sprintf("%s (%.0f) [%s] [%s]", details.Title, details.Year, torrent.Quality, torrent.Type)
+							}
+						} else {
+							Metrics.TorrentsParsed.Inc(Movie.MOVIE_SOURCE_YTS)
 						}
 
+						// Names are parsed for the fields the API left empty.
+						Movie.FillMissingTorrentReleaseFields(torrent)
+
 						appendListMutex.Lock()
 						torrents = append(torrents, torrent)
 						appendListMutex.Unlock()
-					}, Config.Main.TasksExecutionDelay.YTS_TORRENT_PARSER)
+					}, Config.Get().TasksExecutionDelay.YTS_TORRENT_PARSER)
 				}
 			}
 
@@ -257,9 +361,15 @@ func parseMovieDetailsFromJsonData(client *Client, details *Movie.MovieDetails,
 
 	setMovieDetail(&details.DateUploaded, jsonData, "date_uploaded")
 	setMovieDetail(&details.DateUploadedUnix, jsonData, "date_uploaded_unix")
+
+	Movie.NormalizeMovieDetailsTitle(details)
 }
 
 func (this *Client) GetMovieList(params *MoviesListParameters) ([]*Movie.MovieDetails, error, float64) {
+	return this.GetMovieListWithContext(this.Context, params)
+}
+
+func (this *Client) GetMovieListWithContext(ctx context.Context, params *MoviesListParameters) ([]*Movie.MovieDetails, error, float64) {
 	url, err := url.Parse(this.ListMoviesEndpoint)
 
 	var queryParams *MoviesListParameters = NewMoviesListParameters()
@@ -274,7 +384,7 @@ func (this *Client) GetMovieList(params *MoviesListParameters) ([]*Movie.MovieDe
 		return nil, err, 0
 	}
 
-	moviesJsonData, err := this.fetch(url, "GET", nil)
+	moviesJsonData, err := this.fetch(ctx, url, "GET", nil)
 
 	if err != nil {
 		return nil, err, 0
@@ -304,9 +414,11 @@ func (this *Client) GetMovieList(params *MoviesListParameters) ([]*Movie.MovieDe
 
 	var appendListMutex sync.Mutex
 
-	tmContext, _ := context.WithTimeout(this.Context, this.Timeout)
+	tmContext, tmContextCancel := context.WithTimeout(ctx, this.Timeout)
+
+	defer tmContextCancel()
 
-	var movieParserTaskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(tmContext, "YTS_MOVIE_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(queryParams))), Config.Main.TasksMaxThreads.YTS_MOVIE_PARSER)
+	var movieParserTaskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(tmContext, "YTS_MOVIE_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(queryParams))), Config.Get().TasksMaxThreads.YTS_MOVIE_PARSER)
 
 	movieParserTaskManager.Start()
 
@@ -314,18 +426,21 @@ func (this *Client) GetMovieList(params *MoviesListParameters) ([]*Movie.MovieDe
 		item, ok := value.(map[string]interface{})
 
 		if !ok {
+			Metrics.MoviesParseFailures.Inc(Movie.MOVIE_SOURCE_YTS)
 			continue
 		}
 
 		var details *Movie.MovieDetails = Movie.NewMovieDetails()
 
 		movieParserTaskManager.AddTaskWithDelay(func(t *TaskManager.Task) {
-			parseMovieDetailsFromJsonData(this, details, &item)
+			parseMovieDetailsFromJsonData(t.Context, this, details, &item)
+
+			Metrics.MoviesParsed.Inc(Movie.MOVIE_SOURCE_YTS)
 
 			appendListMutex.Lock()
 			moviesListResult = append(moviesListResult, details)
 			appendListMutex.Unlock()
-		}, Config.Main.TasksExecutionDelay.YTS_MOVIE_PARSER)
+		}, Config.Get().TasksExecutionDelay.YTS_MOVIE_PARSER)
 	}
 
 	movieParserTaskManager.WaitForTasks()
@@ -336,6 +451,10 @@ func (this *Client) GetMovieList(params *MoviesListParameters) ([]*Movie.MovieDe
 }
 
 func (this *Client) GetMovieCount(params *MoviesListParameters) (float64, error) {
+	return this.GetMovieCountWithContext(this.Context, params)
+}
+
+func (this *Client) GetMovieCountWithContext(ctx context.Context, params *MoviesListParameters) (float64, error) {
 	url, err := url.Parse(this.ListMoviesEndpoint)
 
 	var queryParams *MoviesListParameters = NewMoviesListParameters()
@@ -350,7 +469,7 @@ func (this *Client) GetMovieCount(params *MoviesListParameters) (float64, error)
 		return 0, err
 	}
 
-	moviesJsonData, err := this.fetch(url, "GET", nil)
+	moviesJsonData, err := this.fetch(ctx, url, "GET", nil)
 
 	if err != nil {
 		return 0, err
@@ -368,6 +487,10 @@ func (this *Client) GetMovieCount(params *MoviesListParameters) (float64, error)
 }
 
 func (this *Client) GetMovieDetails(params *MovieDetailsParameters) (*Movie.MovieDetails, error) {
+	return this.GetMovieDetailsWithContext(this.Context, params)
+}
+
+func (this *Client) GetMovieDetailsWithContext(ctx context.Context, params *MovieDetailsParameters) (*Movie.MovieDetails, error) {
 	url, err := url.Parse(this.MovieDetailsEndpoint)
 
 	var queryParams *MovieDetailsParameters = NewMovieDetailsParameters(INVALID_MOVIE_DETAILS_PARAMETERS_ID)
@@ -382,7 +505,7 @@ func (this *Client) GetMovieDetails(params *MovieDetailsParameters) (*Movie.Movi
 		return nil, err
 	}
 
-	dataJson, err := this.fetch(url, "GET", nil)
+	dataJson, err := this.fetch(ctx, url, "GET", nil)
 
 	if err != nil {
 		return nil, err
@@ -402,12 +525,16 @@ func (this *Client) GetMovieDetails(params *MovieDetailsParameters) (*Movie.Movi
 
 	var details *Movie.MovieDetails = Movie.NewMovieDetails()
 
-	parseMovieDetailsFromJsonData(this, details, &movieDetails)
+	parseMovieDetailsFromJsonData(ctx, this, details, &movieDetails)
 
 	return details, nil
 }
 
 func (this *Client) GetMovieSuggestions(params *MovieSuggestionsParameters) ([]*Movie.MovieDetails, error, float64) {
+	return this.GetMovieSuggestionsWithContext(this.Context, params)
+}
+
+func (this *Client) GetMovieSuggestionsWithContext(ctx context.Context, params *MovieSuggestionsParameters) ([]*Movie.MovieDetails, error, float64) {
 	url, err := url.Parse(this.MovieSuggestionsEndpoint)
 
 	var queryParams *MovieSuggestionsParameters = NewMovieSuggestionsParameters(INVALID_MOVIE_DETAILS_PARAMETERS_ID)
@@ -422,7 +549,7 @@ func (this *Client) GetMovieSuggestions(params *MovieSuggestionsParameters) ([]*
 		return nil, err, 0
 	}
 
-	moviesJsonData, err := this.fetch(url, "GET", nil)
+	moviesJsonData, err := this.fetch(ctx, url, "GET", nil)
 
 	if err != nil {
 		return nil, err, 0
@@ -452,7 +579,7 @@ func (this *Client) GetMovieSuggestions(params *MovieSuggestionsParameters) ([]*
 
 	var appendListMutex sync.Mutex
 
-	var movieParserTaskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(this.Context, "YTS_MOVIE_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(queryParams))), Config.Main.TasksMaxThreads.YTS_MOVIE_PARSER)
+	var movieParserTaskManager *TaskManager.TaskManager = TaskManager.CreateTaskManagerWithContext(ctx, "YTS_MOVIE_PARSER_"+fmt.Sprintf("%d", (uintptr)(unsafe.Pointer(queryParams))), Config.Get().TasksMaxThreads.YTS_MOVIE_PARSER)
 
 	movieParserTaskManager.Start()
 
@@ -460,18 +587,21 @@ func (this *Client) GetMovieSuggestions(params *MovieSuggestionsParameters) ([]*
 		item, ok := value.(map[string]interface{})
 
 		if !ok {
+			Metrics.MoviesParseFailures.Inc(Movie.MOVIE_SOURCE_YTS)
 			continue
 		}
 
 		var details *Movie.MovieDetails = Movie.NewMovieDetails()
 
 		movieParserTaskManager.AddTaskWithDelay(func(t *TaskManager.Task) {
-			parseMovieDetailsFromJsonData(this, details, &item)
+			parseMovieDetailsFromJsonData(t.Context, this, details, &item)
+
+			Metrics.MoviesParsed.Inc(Movie.MOVIE_SOURCE_YTS)
 
 			appendListMutex.Lock()
 			moviesListResult = append(moviesListResult, details)
 			appendListMutex.Unlock()
-		}, Config.Main.TasksExecutionDelay.YTS_MOVIE_PARSER)
+		}, Config.Get().TasksExecutionDelay.YTS_MOVIE_PARSER)
 	}
 
 	movieParserTaskManager.WaitForTasks()
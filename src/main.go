@@ -1,30 +1,128 @@
 package main
 
 import (
+	"GServer/Auth"
+	"GServer/Catalog"
 	"GServer/Config"
 	"GServer/Crawler"
+	"GServer/Events"
 	"GServer/HttpServer"
 	"GServer/Logger"
 	"GServer/TaskManager"
+	"GServer/Tracing"
+	"GServer/Webhooks"
 	"GServer/YTS"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
+const (
+	COMMAND_VALIDATE_CONFIG = "validate-config"
+)
+
+// Prints the effective config and where each value came from.
+func printConfig() int {
+	Config.ReadConfig()
+
+	if err := Config.WriteEffectiveConfig(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, problem := range Config.Problems {
+		fmt.Fprintln(os.Stderr, problem.String())
+	}
+
+	if Config.LoadError != nil {
+		return 1
+	}
+
+	return 0
+}
+
+// Checks the config without starting anything, exits with 1 on fatal problems.
+func validateConfig() int {
+	Config.ReadConfig()
+
+	for _, problem := range Config.Problems {
+		fmt.Println(problem.String())
+	}
+
+	if Config.LoadError != nil {
+		fmt.Printf("Config is invalid, %d problem(s) found.\n", len(Config.Problems))
+		return 1
+	}
+
+	fmt.Printf("Config is valid, %d warning(s).\n", len(Config.Problems))
+
+	return 0
+}
+
 func main() {
-	fmt.Println(
-		" ██████╗ ███████╗███████╗██████╗ ██╗   ██╗███████╗██████╗ \n" +
-			"██╔════╝ ██╔════╝██╔════╝██╔══██╗██║   ██║██╔════╝██╔══██╗\n" +
-			"██║  ███╗███████╗█████╗  ██████╔╝██║   ██║█████╗  ██████╔╝\n" +
-			"██║   ██║╚════██║██╔══╝  ██╔══██╗╚██╗ ██╔╝██╔══╝  ██╔══██╗\n" +
-			"╚██████╔╝███████║███████╗██║  ██║ ╚████╔╝ ███████╗██║  ██║\n" +
-			" ╚═════╝ ╚══════╝╚══════╝╚═╝  ╚═╝  ╚═══╝  ╚══════╝╚═╝  ╚═╝\n",
-	)
+	var arguments []string = os.Args[1:]
+	var command string = ""
+
+	if len(arguments) > 0 && arguments[0] == COMMAND_VALIDATE_CONFIG {
+		command = arguments[0]
+		arguments = arguments[1:]
+	}
+
+	if err := Config.ParseArguments(os.Args[0], arguments); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+
+		os.Exit(2)
+	}
+
+	if command == COMMAND_VALIDATE_CONFIG || Config.PrintConfig {
+		// Keeps stdout for the command's own output.
+		Logger.SetSinks([]Logger.Sink{{Writer: os.Stderr, Format: Logger.LOG_FORMAT_TEXT, Level: Logger.LOG_LEVEL_DEBUG}})
+
+		if command == COMMAND_VALIDATE_CONFIG {
+			os.Exit(validateConfig())
+		}
+
+		os.Exit(printConfig())
+	}
 
 	TaskManager.Initialize()
-	Config.Initialize()
-	HttpServer.Initialize()
+
+	if err := Config.Initialize(); err != nil {
+		Logger.ERROR("Invalid config, shutting down. Run with "+COMMAND_VALIDATE_CONFIG+" for details.", "error", err)
+
+		Config.Uninitialize()
+		TaskManager.Uninitialize()
+
+		os.Exit(1)
+	}
+
+	Tracing.Initialize()
+	Events.Initialize()
+	Catalog.Initialize()
+	Webhooks.Initialize()
+	Auth.Initialize()
+
+	if err := HttpServer.Initialize(); err != nil {
+		Logger.ERROR("Couldn't start HTTP server, shutting down.", "error", err)
+
+		Auth.Uninitialize()
+		Webhooks.Uninitialize()
+		Catalog.Uninitialize()
+		Events.Uninitialize()
+		Tracing.Uninitialize()
+		Config.Uninitialize()
+		TaskManager.Uninitialize()
+
+		os.Exit(1)
+	}
+
 	Crawler.Initialize()
 
 	{
@@ -38,19 +136,33 @@ func main() {
 			movies, err, movieCount := ytsClient.GetMovieList(params)
 
 			if err != nil {
-				Logger.ERROR("yts err is : ", err.Error())
+				Logger.ERROR("Failed getting YTS movies.", "error", err)
 			} else {
-				Logger.INFO("yts movies are : ", movies[0].Title, " | ", movies[0].Torrents[0].MainFile, " | ", movieCount)
+				Logger.INFO("Got YTS movies.", "title", movies[0].Title, "main_file", movies[0].Torrents[0].MainFile, "movie_count", movieCount)
 			}
 		}
 	}
 
 	Logger.INFO("getting film info done!")
 
-	TaskManager.Wait()
+	ctx, stop := signal.NotifyContext(TaskManager.MainContext, os.Interrupt, syscall.SIGTERM)
+
+	<-ctx.Done()
+
+	// A second signal kills the process right away.
+	stop()
+
+	Logger.INFO("Shutting down...")
+
+	TaskManager.MainContextCancel()
 
 	Crawler.Uninitialize()
 	HttpServer.Uninitialize()
+	Auth.Uninitialize()
+	Webhooks.Uninitialize()
+	Catalog.Uninitialize()
+	Events.Uninitialize()
+	Tracing.Uninitialize()
 	Config.Uninitialize()
 	TaskManager.Uninitialize()
 }